@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strconv"
+
+	"github.com/afroash/mygame/logic"
+)
+
+// command is a headless subcommand, run as "mygame <name> [flags] [file]"
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) error
+
+// commands lists the subcommands by name
+var commands = map[string]command{
+	"dedupe":   runDedupe,
+	"solve":    runSolve,
+	"grade":    runGrade,
+	"generate": runGenerate,
+	"convert":  runConvert,
+	"validate": runValidate,
+}
+
+// runCommand runs the subcommand named by the first argument. It reports
+// false when there is none so the game starts as usual.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+	if err := cmd(args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		fmt.Fprintf(os.Stderr, "mygame %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// readInput reads the file named in args, or stdin when there is none
+func readInput(args []string, stdin io.Reader) ([]byte, error) {
+	switch len(args) {
+	case 0:
+		return io.ReadAll(stdin)
+	case 1:
+		return os.ReadFile(args[0])
+	default:
+		return nil, fmt.Errorf("expected one input file, got %d", len(args))
+	}
+}
+
+// readInputPack reads the pack named in args, or stdin when there is none.
+// An empty format name means detecting the format from the content.
+func readInputPack(args []string, stdin io.Reader, formatName string) (*logic.Pack, logic.Format, error) {
+	data, err := readInput(args, stdin)
+	if err != nil {
+		return nil, 0, err
+	}
+	format := logic.DetectFormat(data)
+	if formatName != "" {
+		if format, err = logic.ParseFormat(formatName); err != nil {
+			return nil, 0, err
+		}
+	}
+	pack, err := logic.ReadPackFormat(bytes.NewReader(data), format)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pack, format, nil
+}
+
+// outputFormat returns the named format, or def when the name is empty
+func outputFormat(name string, def logic.Format) (logic.Format, error) {
+	if name == "" {
+		return def, nil
+	}
+	return logic.ParseFormat(name)
+}
+
+// openOutput returns where to write results: the named file, or stdout when
+// the name is empty. The returned function closes the file.
+func openOutput(name string, stdout io.Writer) (io.Writer, func() error, error) {
+	if name == "" {
+		return stdout, func() error { return nil }, nil
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create output: %v", err)
+	}
+	return file, file.Close, nil
+}
+
+// writeOutput writes the pack to the named file, or stdout when the name is empty
+func writeOutput(name string, stdout io.Writer, format logic.Format, pack *logic.Pack) error {
+	w, closeOutput, err := openOutput(name, stdout)
+	if err != nil {
+		return err
+	}
+	if err := logic.WritePack(w, format, pack); err != nil {
+		closeOutput()
+		return err
+	}
+	return closeOutput()
+}
+
+// runDedupe removes puzzles that are equivalent to an earlier one in the
+// pack, reporting each one it drops
+func runDedupe(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("dedupe", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the deduplicated pack to `file` instead of stdout")
+	formatName := fs.String("format", "", "output `format`, the input's format by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pack, format, err := readInputPack(fs.Args(), stdin, "")
+	if err != nil {
+		return err
+	}
+	if format, err = outputFormat(*formatName, format); err != nil {
+		return err
+	}
+
+	dupes := logic.FindDuplicates(pack.Grids())
+	drop := make(map[int]bool)
+	for _, d := range dupes {
+		drop[d.Index] = true
+		fmt.Fprintf(stderr, "puzzle %s duplicates puzzle %s\n", pack.Puzzles[d.Index].ID, pack.Puzzles[d.Original].ID)
+	}
+	kept := pack.Puzzles[:0]
+	for i, pp := range pack.Puzzles {
+		if !drop[i] {
+			kept = append(kept, pp)
+		}
+	}
+	pack.Puzzles = kept
+	fmt.Fprintf(stderr, "removed %d duplicates, %d puzzles left\n", len(dupes), len(kept))
+
+	return writeOutput(*output, stdout, format, pack)
+}
+
+// runSolve replaces every puzzle of the pack with its solution. Puzzles that
+// can't be solved are reported and left out.
+func runSolve(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("solve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "", "write the solutions to `file` instead of stdout")
+	formatName := fs.String("format", "", "output `format`, the input's format by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pack, format, err := readInputPack(fs.Args(), stdin, "")
+	if err != nil {
+		return err
+	}
+	if format, err = outputFormat(*formatName, format); err != nil {
+		return err
+	}
+
+	total := len(pack.Puzzles)
+	solved := pack.Puzzles[:0]
+	for _, pp := range pack.Puzzles {
+		solution, err := logic.Solve(pp.Puzzle)
+		if err != nil {
+			fmt.Fprintf(stderr, "puzzle %s: %v\n", pp.ID, err)
+			continue
+		}
+		if !logic.HasUniqueSolution(pp.Puzzle) {
+			fmt.Fprintf(stderr, "puzzle %s: has more than one solution, writing the first\n", pp.ID)
+		}
+		pp.Puzzle, pp.Solution = solution, nil
+		solved = append(solved, pp)
+	}
+	pack.Puzzles = solved
+
+	if err := writeOutput(*output, stdout, format, pack); err != nil {
+		return err
+	}
+	if failed := total - len(solved); failed > 0 {
+		return fmt.Errorf("%d of %d puzzles could not be solved", failed, total)
+	}
+	return nil
+}
+
+// runGrade prints the difficulty of every puzzle of the pack, one per line:
+// id, level, hardest technique needed and score, separated by tabs
+func runGrade(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("grade", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pack, _, err := readInputPack(fs.Args(), stdin, "")
+	if err != nil {
+		return err
+	}
+	for _, pp := range pack.Puzzles {
+		if !logic.HasUniqueSolution(pp.Puzzle) {
+			fmt.Fprintf(stdout, "%s\tinvalid\n", pp.ID)
+			continue
+		}
+		rating := logic.Grade(pp.Puzzle)
+		fmt.Fprintf(stdout, "%s\t%v\t%v\t%d\n", pp.ID, ratingLevel(rating), rating.Hardest, rating.Score)
+	}
+	return nil
+}
+
+// runGenerate builds a pack of new puzzles at a difficulty. With a seed the
+// same pack comes out every time.
+func runGenerate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	count := fs.Int("n", 1, "number of puzzles to generate")
+	ratingName := fs.String("rating", "medium", "difficulty `level`: easy, medium or hard")
+	removalName := fs.String("symmetry", defaultRemoval.String(), "clue removal `strategy`: random, rotational, mirror, diagonal or minimal")
+	seed := fs.Int64("seed", 0, "generate the pack from `seed`, a random one by default")
+	output := fs.String("o", "", "write the pack to `file` instead of stdout")
+	formatName := fs.String("format", "line", "output `format`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *count < 1 {
+		return fmt.Errorf("invalid puzzle count %d", *count)
+	}
+
+	level, err := parseDifficulty(*ratingName)
+	if err != nil {
+		return err
+	}
+	removal, err := logic.ParseRemovalStrategy(*removalName)
+	if err != nil {
+		return err
+	}
+	format, err := logic.ParseFormat(*formatName)
+	if err != nil {
+		return err
+	}
+
+	// Each puzzle gets its own seed, drawn from the pack's seed when given
+	nextSeed := newSeed
+	if *seed != 0 {
+		rng := rand.New(rand.NewSource(*seed))
+		nextSeed = func() int64 { return rng.Int63n(maxSeed-1) + 1 }
+	}
+
+	spec := puzzleSpec(queueKey{level, removal}, nil)
+	pack := &logic.Pack{Title: fmt.Sprintf("Generated %v puzzles", level)}
+	for i := 1; i <= *count; i++ {
+		puzzleSeed := nextSeed()
+		puzzle, rating, err := logic.Generator{}.Generate(context.Background(), puzzleSeed, spec)
+		if err != nil {
+			return fmt.Errorf("puzzle %d: %v", i, err)
+		}
+		if got := ratingLevel(rating); got != level {
+			fmt.Fprintf(stderr, "puzzle %d: settled for a %v puzzle\n", i, got)
+		}
+		pack.Puzzles = append(pack.Puzzles, logic.PackPuzzle{
+			ID:         strconv.Itoa(i),
+			Puzzle:     puzzle,
+			Difficulty: ratingLevel(rating).String(),
+		})
+	}
+	return writeOutput(*output, stdout, format, pack)
+}
+
+// runConvert rewrites a pack in another format
+func runConvert(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "", "input `format`, detected from the content by default")
+	to := fs.String("to", "", "output `format` (required)")
+	output := fs.String("o", "", "write the pack to `file` instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *to == "" {
+		return errors.New("missing -to format")
+	}
+	format, err := logic.ParseFormat(*to)
+	if err != nil {
+		return err
+	}
+
+	pack, _, err := readInputPack(fs.Args(), stdin, *from)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, stdout, format, pack)
+}
+
+// runValidate checks that every puzzle of the pack keeps to the rules and has
+// exactly one solution, reporting each one that doesn't
+func runValidate(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// Reading the pack already checks ids and any solutions it gives
+	pack, _, err := readInputPack(fs.Args(), stdin, "")
+	if err != nil {
+		return err
+	}
+	invalid := 0
+	for _, pp := range pack.Puzzles {
+		var problem string
+		if _, err := logic.Solve(pp.Puzzle); err != nil {
+			problem = err.Error()
+		} else if !logic.HasUniqueSolution(pp.Puzzle) {
+			problem = "puzzle has more than one solution"
+		}
+		if problem != "" {
+			invalid++
+			fmt.Fprintf(stdout, "puzzle %s: %s\n", pp.ID, problem)
+		}
+	}
+	fmt.Fprintf(stderr, "checked %d puzzles, %d invalid\n", len(pack.Puzzles), invalid)
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d puzzles are invalid", invalid, len(pack.Puzzles))
+	}
+	return nil
+}
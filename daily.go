@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/afroash/mygame/logic"
+)
+
+// The daily puzzle is always played at this level and clue layout, so
+// everyone gets the same board
+const (
+	dailyDifficulty = Medium
+	dailyRemoval    = logic.RotationalRemoval
+)
+
+// dailyDateLayout is how daily dates are written in the stats and saves
+const dailyDateLayout = "2006-01-02"
+
+// dailyStats tracks the daily puzzles solved and the run of days in a row
+type dailyStats struct {
+	LastCompleted string `json:"last_completed,omitempty"` // Date of the latest daily solved
+	Completed     int    `json:"completed"`
+	Streak        int    `json:"streak"` // Days in a row up to LastCompleted
+	BestStreak    int    `json:"best_streak"`
+}
+
+// dailyDate returns the local calendar date of t as used for the daily puzzle
+func dailyDate(t time.Time) string {
+	return t.Format(dailyDateLayout)
+}
+
+// dailySeed derives the puzzle seed from the local date, e.g. 20240131
+func dailySeed(t time.Time) int64 {
+	year, month, day := t.Date()
+	return int64(year*10000 + int(month)*100 + day)
+}
+
+// previousDay returns the date before a daily date, empty if it doesn't parse
+func previousDay(date string) string {
+	day, err := time.Parse(dailyDateLayout, date)
+	if err != nil {
+		return ""
+	}
+	return dailyDate(day.AddDate(0, 0, -1))
+}
+
+// doneOn reports whether the daily for the date has been solved
+func (d *dailyStats) doneOn(date string) bool {
+	return d.LastCompleted == date
+}
+
+// currentStreak returns the streak still alive on the given date. Missing
+// yesterday's daily breaks it even before today's is played.
+func (d *dailyStats) currentStreak(today string) int {
+	if d.LastCompleted == today || d.LastCompleted == previousDay(today) {
+		return d.Streak
+	}
+	return 0
+}
+
+// complete records the daily for the date as solved, reporting false when it
+// was already counted or a later daily has been solved since
+func (d *dailyStats) complete(date string) bool {
+	if d.LastCompleted >= date {
+		return false
+	}
+	if d.LastCompleted != "" && d.LastCompleted == previousDay(date) {
+		d.Streak++
+	} else {
+		d.Streak = 1
+	}
+	d.LastCompleted = date
+	d.Completed++
+	d.BestStreak = max(d.BestStreak, d.Streak)
+	return true
+}
+
+// startDaily starts the daily puzzle for the date of now. It is always built
+// from a fresh grid rather than the loaded packs, so it doesn't depend on
+// how the game is set up, and leaves the level and layout picked for new
+// games alone. The puzzle is generated in the background.
+func (g *Game) startDaily(now time.Time) {
+	date := dailyDate(now)
+	g.generateGame(queueKey{dailyDifficulty, dailyRemoval}, dailySeed(now), nil, func(p queuedPuzzle) {
+		g.playDaily(p, date)
+	})
+	g.showStatus("Generating today's puzzle...", infoMessage, longMessageDuration)
+}
+
+// playDaily starts the generated daily puzzle for the date
+func (g *Game) playDaily(p queuedPuzzle, date string) {
+	g.beginGame(p.puzzle, p.rating, p.key.removal)
+	g.daily = date
+
+	if g.stats != nil && g.stats.Daily.doneOn(date) {
+		g.showStatus(fmt.Sprintf("Already solved today's puzzle, streak: %d", g.stats.Daily.Streak),
+			infoMessage, normalMessageDuration)
+		return
+	}
+	streak := 0
+	if g.stats != nil {
+		streak = g.stats.Daily.currentStreak(date)
+	}
+	g.showStatus(fmt.Sprintf("Daily puzzle for %s, streak: %d", date, streak), infoMessage, normalMessageDuration)
+}
+
+// recordDaily counts the current daily puzzle towards the streak
+func (g *Game) recordDaily() {
+	if g.daily == "" || g.stats == nil || !g.stats.Daily.complete(g.daily) {
+		return
+	}
+	if err := g.stats.save(); err != nil {
+		g.showStatus(fmt.Sprintf("Error saving statistics: %v", err), errorMessage, normalMessageDuration)
+		return
+	}
+	g.showStatus(fmt.Sprintf("Daily puzzle solved! Streak: %d (best %d)", g.stats.Daily.Streak, g.stats.Daily.BestStreak),
+		successMessage, longMessageDuration)
+}
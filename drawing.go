@@ -3,7 +3,11 @@ package main
 import (
 	"fmt"
 	"image/color"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/afroash/mygame/logic"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/text/v2"
 	"github.com/hajimehoshi/ebiten/v2/vector"
@@ -46,6 +50,16 @@ func (d *DrawHandler) Draw(screen *ebiten.Image) {
 		d.drawMainMenu(screen)
 	case DifficultyMenu:
 		d.drawDifficultyMenu(screen)
+	case LoadMenu:
+		d.drawLoadMenu(screen)
+	case Paused:
+		d.drawPauseScreen(screen)
+	case StatsMenu:
+		d.drawStatsMenu(screen)
+	case PackMenu:
+		d.drawPackMenu(screen)
+	case SeedMenu:
+		d.drawSeedMenu(screen)
 	case Playing:
 		if d.game.logic != nil {
 			// Add a title at the top
@@ -60,6 +74,8 @@ func (d *DrawHandler) Draw(screen *ebiten.Image) {
 				Size:   menuFontSize,
 			}, titleOp)
 
+			d.drawSeedInfo(screen)
+			d.drawHint(screen)
 			d.DrawGrid(screen)
 			d.DrawNumbers(screen)
 			d.drawStatusBar(screen)
@@ -68,6 +84,34 @@ func (d *DrawHandler) Draw(screen *ebiten.Image) {
 	}
 }
 
+// drawSeedInfo shows what rebuilds the current game in the top left corner:
+// the seed or daily date, with the level and clue layout it was made at
+func (d *DrawHandler) drawSeedInfo(screen *ebiten.Image) {
+	if d.game.seed == 0 && d.game.daily == "" {
+		return
+	}
+	label := fmt.Sprintf("Seed: %d", d.game.seed)
+	if d.game.daily != "" {
+		label = "Daily: " + d.game.daily
+	}
+	label += "\n" + d.game.level.String()
+	if d.game.layout != logic.UnknownRemoval {
+		label += fmt.Sprintf(", %v clues", d.game.layout)
+	}
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(10), float64(d.gridTop/2))
+	op.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	op.PrimaryAlign = text.AlignStart
+	op.SecondaryAlign = text.AlignCenter
+	op.LineSpacing = normalFontSize * 1.4
+
+	text.Draw(screen, label, &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, op)
+}
+
 // DrawGrid draws the 9x9 grid.
 func (d *DrawHandler) DrawGrid(screen *ebiten.Image) {
 	lineColor := color.RGBA{0, 0, 0, 255} // Black
@@ -115,6 +159,9 @@ func (d *DrawHandler) DrawGrid(screen *ebiten.Image) {
 	if d.game.specialEnterMode {
 		highlightColor = color.RGBA{0, 255, 0, 255}
 	}
+	if d.game.specialEnterMode && d.game.centreMode {
+		highlightColor = color.RGBA{0, 120, 255, 255}
+	}
 	if !d.game.specialEnterMode {
 		highlightColor = color.RGBA{255, 0, 0, 255}
 	}
@@ -130,76 +177,101 @@ func (d *DrawHandler) DrawGrid(screen *ebiten.Image) {
 	)
 }
 
-// DrawPencilMarks draws pencil marks in the corners of a cell
-func (d *DrawHandler) DrawPencilMarks(screen *ebiten.Image, row, col int, marks map[int]bool) {
-	if len(marks) == 0 {
-		return
-	}
-
-	// Convert map to sorted slice for consistent corner assignment
-	// Iterate 1-9 to get sorted order
-	var sortedMarks []int
+// sortedMarks lists the digits set in a pencil mark mask, lowest first
+func sortedMarks(marks uint16) []int {
+	var nums []int
 	for num := 1; num <= 9; num++ {
-		if marks[num] {
-			sortedMarks = append(sortedMarks, num)
+		if marks&(1<<num) != 0 {
+			nums = append(nums, num)
 		}
 	}
+	return nums
+}
 
-	// Limit to 4 marks (one per corner)
-	if len(sortedMarks) > 4 {
-		sortedMarks = sortedMarks[:4]
-	}
-
-	// Corner positions with padding
-	padding := 7
-	cellX := col * d.cellSize
-	cellY := d.gridTop + row*d.cellSize
-
-	// Define corner positions and alignments
-	type cornerInfo struct {
-		x, y           int
-		primaryAlign   text.Align
-		secondaryAlign text.Align
-	}
-
-	corners := []cornerInfo{
-		{cellX + padding, cellY + padding, text.AlignStart, text.AlignStart},                       // Top-left
-		{cellX + d.cellSize - padding, cellY + padding, text.AlignEnd, text.AlignStart},            // Top-right
-		{cellX + padding, cellY + d.cellSize - padding, text.AlignStart, text.AlignEnd},            // Bottom-left
-		{cellX + d.cellSize - padding, cellY + d.cellSize - padding, text.AlignEnd, text.AlignEnd}, // Bottom-right
+// DrawPencilMarks draws a cell's pencil marks in the selected notation. Bit n
+// of marks is set when n is pencilled in.
+func (d *DrawHandler) DrawPencilMarks(screen *ebiten.Image, row, col int, marks uint16) {
+	if marks == 0 {
+		return
 	}
+	sortedMarks := sortedMarks(marks)
 
-	// Draw each mark in its corner
+	cellX := float64(col * d.cellSize)
+	cellY := float64(d.gridTop + row*d.cellSize)
+	size := float64(d.cellSize)
 	pencilFontSize := float64(normalFontSize - 4)
 	pencilColor := color.RGBA{150, 150, 150, 255} // Gray color for pencil marks
 
-	for i, num := range sortedMarks {
-		if i >= len(corners) {
-			break
-		}
-
-		corner := corners[i]
-		numStr := string(rune(num + '0'))
-
+	drawMark := func(s string, x, y, fontSize float64) {
 		op := &text.DrawOptions{}
-		op.GeoM.Translate(float64(corner.x), float64(corner.y))
+		op.GeoM.Translate(cellX+x, cellY+y)
 		op.ColorScale.ScaleWithColor(pencilColor)
-		op.PrimaryAlign = corner.primaryAlign
-		op.SecondaryAlign = corner.secondaryAlign
-
-		text.Draw(screen, numStr, &text.GoTextFace{
+		op.PrimaryAlign = text.AlignCenter
+		op.SecondaryAlign = text.AlignCenter
+		text.Draw(screen, s, &text.GoTextFace{
 			Source: d.fontSource,
-			Size:   pencilFontSize,
+			Size:   fontSize,
 		}, op)
 	}
+
+	switch d.game.markStyle {
+	case cornerMarks:
+		// Corners first, then the middle of each edge, then the centre
+		inset := 10.0
+		positions := [9][2]float64{
+			{inset, inset}, {size - inset, inset}, {inset, size - inset}, {size - inset, size - inset},
+			{size / 2, inset}, {size / 2, size - inset}, {inset, size / 2}, {size - inset, size / 2},
+			{size / 2, size / 2},
+		}
+		for i, num := range sortedMarks {
+			drawMark(strconv.Itoa(num), positions[i][0], positions[i][1], pencilFontSize)
+		}
+	default:
+		// Each digit keeps its own spot, 1 top left through 9 bottom right
+		for _, num := range sortedMarks {
+			x := size * float64(2*((num-1)%3)+1) / 6
+			y := size * float64(2*((num-1)/3)+1) / 6
+			drawMark(strconv.Itoa(num), x, y, pencilFontSize)
+		}
+	}
 }
 
-// DrawNumbers draws the numbers on the grid
+// DrawCentreMarks draws a cell's centre marks in a row across the middle, in
+// blue to tell them from the pencil marks around them
+func (d *DrawHandler) DrawCentreMarks(screen *ebiten.Image, row, col int, marks uint16) {
+	if marks == 0 {
+		return
+	}
+	var digits strings.Builder
+	nums := sortedMarks(marks)
+	for _, num := range nums {
+		digits.WriteString(strconv.Itoa(num))
+	}
+
+	// Smaller when there are many so they stay inside the cell
+	fontSize := float64(normalFontSize - 3)
+	if len(nums) > 5 {
+		fontSize--
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(col*d.cellSize+d.cellSize/2), float64(d.gridTop+row*d.cellSize+d.cellSize/2))
+	op.ColorScale.ScaleWithColor(color.RGBA{60, 110, 190, 255})
+	op.PrimaryAlign = text.AlignCenter
+	op.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, digits.String(), &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   fontSize,
+	}, op)
+}
+
+// DrawNumbers draws the numbers on the grid. Given clues are drawn in black
+// and the player's entries in blue.
 func (d *DrawHandler) DrawNumbers(screen *ebiten.Image) {
 	if d.game == nil || d.game.logic == nil {
 		fmt.Println("Game or logic is nil")
 		return
 	}
+	entryColor := color.RGBA{30, 90, 200, 255} // Blue for player entries
 	for row := 0; row < d.gridSize; row++ {
 		for col := 0; col < d.gridSize; col++ {
 			if d.game.logic.Puzzle[row][col] != 0 {
@@ -208,9 +280,14 @@ func (d *DrawHandler) DrawNumbers(screen *ebiten.Image) {
 				x := col*d.cellSize + d.cellSize/2
 				y := d.gridTop + row*d.cellSize + d.cellSize/2
 
+				numColor := color.Color(color.Black)
+				if !d.game.logic.IsGiven(row, col) {
+					numColor = entryColor
+				}
+
 				op := &text.DrawOptions{}
 				op.GeoM.Translate(float64(x), float64(y))
-				op.ColorScale.ScaleWithColor(color.Black)
+				op.ColorScale.ScaleWithColor(numColor)
 				op.PrimaryAlign = text.AlignCenter
 				op.SecondaryAlign = text.AlignCenter
 
@@ -219,17 +296,22 @@ func (d *DrawHandler) DrawNumbers(screen *ebiten.Image) {
 					Source: d.fontSource,
 					Size:   normalFontSize,
 				}, op)
-				
+
 				// Draw pencil marks for filled cells when in help mode
-				if d.game.specialEnterMode && len(d.game.pencilMarks[row][col]) > 0 {
-					d.DrawPencilMarks(screen, row, col, d.game.pencilMarks[row][col])
+				if d.game.specialEnterMode && d.game.logic.Marks[row][col] != 0 {
+					d.DrawPencilMarks(screen, row, col, d.game.logic.Marks[row][col])
 				}
 			} else {
 				// Draw pencil marks for empty cells when in help mode
-				if d.game.specialEnterMode && len(d.game.pencilMarks[row][col]) > 0 {
-					d.DrawPencilMarks(screen, row, col, d.game.pencilMarks[row][col])
+				if d.game.specialEnterMode && d.game.logic.Marks[row][col] != 0 {
+					d.DrawPencilMarks(screen, row, col, d.game.logic.Marks[row][col])
 				}
 			}
+
+			// Centre marks show in help mode too, whichever set is being entered
+			if d.game.specialEnterMode && d.game.logic.Centre[row][col] != 0 {
+				d.DrawCentreMarks(screen, row, col, d.game.logic.Centre[row][col])
+			}
 		}
 	}
 }
@@ -240,8 +322,11 @@ func (d *DrawHandler) drawMainMenu(screen *ebiten.Image) {
 	// Center the menu on screen
 	startX := screenWidth / 2
 	startY := screenHeight / 3
+	options := d.game.mainMenuOptions()
 	lineSpacing := 50 // Increased spacing between options
-	options := []string{"New Game", "Difficulty", "Exit"}
+	if len(options) > 6 {
+		lineSpacing = 300 / len(options) // Squeeze longer menus to fit the screen
+	}
 
 	// Draw title
 	titleOp := &text.DrawOptions{}
@@ -259,7 +344,7 @@ func (d *DrawHandler) drawMainMenu(screen *ebiten.Image) {
 		// Calculate text metrics for centering
 		textWidth := len(option) * diffFontSize / 2 // Approximate width
 		rectWidth := float32(textWidth + 40)        // Add padding
-		rectHeight := float32(40)                   // Fixed height for selection rectangle
+		rectHeight := float32(min(40, lineSpacing-4))
 
 		// Draw selection highlight if this option is selected
 		if i == d.game.selected {
@@ -301,13 +386,35 @@ func (d *DrawHandler) drawMainMenu(screen *ebiten.Image) {
 
 	// Draw instructions at the bottom
 	instructOp := &text.DrawOptions{}
-	instructOp.GeoM.Translate(float64(startX), float64(startY+lineSpacing*4))
+	instructOp.GeoM.Translate(float64(startX), float64(startY+lineSpacing*len(options)+20))
 	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
 	instructOp.PrimaryAlign = text.AlignCenter
 	text.Draw(screen, "Use ↑↓ to select, ENTER to confirm", &text.GoTextFace{
 		Source: d.fontSource,
 		Size:   normalFontSize,
 	}, instructOp)
+
+	d.drawMenuStatus(screen)
+}
+
+// drawMenuStatus shows the status message along the bottom of a menu screen
+func (d *DrawHandler) drawMenuStatus(screen *ebiten.Image) {
+	msg := d.game.statusMessage
+	if !msg.isVisible {
+		return
+	}
+
+	face := &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(float64(d.screenWidth/2), float64(d.screenHeight-30))
+	op.ColorScale.ScaleWithColor(msg.color)
+	op.PrimaryAlign = text.AlignCenter
+	op.SecondaryAlign = text.AlignCenter
+	op.LineSpacing = normalFontSize * 1.3
+	text.Draw(screen, wrapText(msg.text, face, float64(d.screenWidth-20)), face, op)
 }
 
 // drawDifficultyMenu method in drawing.go
@@ -317,6 +424,12 @@ func (d *DrawHandler) drawDifficultyMenu(screen *ebiten.Image) {
 	startY := screenHeight / 3
 	lineSpacing := 50 // Increased spacing between options
 	diffs := []string{"Easy", "Medium", "Hard"}
+	// Techniques the grader allows at each level
+	descriptions := []string{
+		"Singles only",
+		"Pairs, triples, pointing and box/line",
+		"X-Wing, Swordfish, wings and chains",
+	}
 
 	// Draw title
 	titleOp := &text.DrawOptions{}
@@ -356,15 +469,435 @@ func (d *DrawHandler) drawDifficultyMenu(screen *ebiten.Image) {
 		}, op)
 	}
 
+	// Describe the selected level
+	if d.game.selected >= 0 && d.game.selected < len(descriptions) {
+		descOp := &text.DrawOptions{}
+		descOp.GeoM.Translate(float64(startX), float64(startY+lineSpacing*3))
+		descOp.ColorScale.ScaleWithColor(color.Black)
+		descOp.PrimaryAlign = text.AlignCenter
+		text.Draw(screen, descriptions[d.game.selected], &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize + 2,
+		}, descOp)
+	}
+
+	// Draw the clue removal strategy
+	removalOp := &text.DrawOptions{}
+	removalOp.GeoM.Translate(float64(startX), float64(startY+lineSpacing*3+35))
+	removalOp.ColorScale.ScaleWithColor(color.Black)
+	removalOp.PrimaryAlign = text.AlignCenter
+	text.Draw(screen, fmt.Sprintf("Clues: < %v >", d.game.removal), &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize + 2,
+	}, removalOp)
+
 	// Draw instruction
 	instructOp := &text.DrawOptions{}
-	instructOp.GeoM.Translate(float64(startX), float64(startY+lineSpacing*4))
+	instructOp.GeoM.Translate(float64(startX), float64(startY+lineSpacing*4+25))
+	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	instructOp.PrimaryAlign = text.AlignCenter
+	text.Draw(screen, "ENTER: Play | ←→: Clue layout | ESC: Back to main menu", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, instructOp)
+
+	d.drawMenuStatus(screen)
+}
+
+// drawPauseScreen covers the grid while the game is paused so the clock
+// can't be gamed
+func (d *DrawHandler) drawPauseScreen(screen *ebiten.Image) {
+	startX := d.screenWidth / 2
+	startY := d.screenHeight / 3
+
+	titleOp := &text.DrawOptions{}
+	titleOp.GeoM.Translate(float64(startX), float64(startY))
+	titleOp.ColorScale.ScaleWithColor(color.Black)
+	titleOp.PrimaryAlign = text.AlignCenter
+	titleOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, "Paused", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize + 8,
+	}, titleOp)
+
+	timeOp := &text.DrawOptions{}
+	timeOp.GeoM.Translate(float64(startX), float64(startY+60))
+	timeOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	timeOp.PrimaryAlign = text.AlignCenter
+	timeOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, "Time: "+formatDuration(d.game.clock.Elapsed()), &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize,
+	}, timeOp)
+
+	instructOp := &text.DrawOptions{}
+	instructOp.GeoM.Translate(float64(startX), float64(startY+140))
 	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
 	instructOp.PrimaryAlign = text.AlignCenter
-	text.Draw(screen, "Press ESC to return to main menu", &text.GoTextFace{
+	text.Draw(screen, "Press TAB or ENTER to resume, ESC for menu", &text.GoTextFace{
 		Source: d.fontSource,
 		Size:   normalFontSize,
 	}, instructOp)
+
+	// List every key of the game, there is no room for them under the grid
+	keysFace := &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}
+	keysOp := &text.DrawOptions{}
+	keysOp.GeoM.Translate(float64(startX), float64(startY+180))
+	keysOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	keysOp.PrimaryAlign = text.AlignCenter
+	keysOp.LineSpacing = normalFontSize * 1.4
+	text.Draw(screen, wrapText(playingKeys, keysFace, float64(d.screenWidth-40)), keysFace, keysOp)
+}
+
+// playingKeys lists the keys that work during a game
+const playingKeys = "H: Pencil Marks | O: Centre Marks | N: Normal | P: Check Progress | I: Hint | 0/Del: Erase | " +
+	"C: Clear Marks | F: Fill Marks | E: Auto Clear | M: Grid/Corner Marks | Z/Backspace: Undo | " +
+	"Y/Shift+Z: Redo | U: Undo Cell | F5: Save | Tab: Pause | ESC: Menu"
+
+// drawLoadMenu lists the save slots with their difficulty, progress and save time
+func (d *DrawHandler) drawLoadMenu(screen *ebiten.Image) {
+	startX := screenWidth / 2
+	listTop := 110
+	entryHeight := 44
+	maxVisible := 8
+
+	// Draw title
+	titleOp := &text.DrawOptions{}
+	titleOp.GeoM.Translate(float64(startX), float64(60))
+	titleOp.ColorScale.ScaleWithColor(color.Black)
+	titleOp.PrimaryAlign = text.AlignCenter
+	titleOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, "Load Game", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize + 4,
+	}, titleOp)
+
+	slots := d.game.slotList
+	if len(slots) == 0 {
+		emptyOp := &text.DrawOptions{}
+		emptyOp.GeoM.Translate(float64(startX), float64(listTop+entryHeight))
+		emptyOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+		emptyOp.PrimaryAlign = text.AlignCenter
+		text.Draw(screen, "No saved games yet. Press F5 while playing to save.", &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize,
+		}, emptyOp)
+	}
+
+	// Scroll so the selected slot is always visible
+	first := 0
+	if d.game.selected >= maxVisible {
+		first = d.game.selected - maxVisible + 1
+	}
+
+	for i := first; i < len(slots) && i < first+maxVisible; i++ {
+		slot := slots[i]
+		yPos := listTop + (i-first)*entryHeight
+
+		if i == d.game.selected {
+			vector.DrawFilledRect(
+				screen,
+				20,
+				float32(yPos),
+				float32(d.screenWidth-40),
+				float32(entryHeight-4),
+				color.RGBA{0, 0, 255, 100},
+				false,
+			)
+		}
+
+		name := slot.Name
+		if i == d.game.selected && d.game.renaming {
+			name = string(d.game.renameBuffer) + "_"
+		}
+		nameOp := &text.DrawOptions{}
+		nameOp.GeoM.Translate(30, float64(yPos+4))
+		nameOp.ColorScale.ScaleWithColor(color.Black)
+		text.Draw(screen, name, &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize + 4,
+		}, nameOp)
+
+		details := fmt.Sprintf("%v | %d%% complete | %s",
+			slot.Difficulty, slot.Progress, slot.SavedAt.Format("2006-01-02 15:04"))
+		detailOp := &text.DrawOptions{}
+		detailOp.GeoM.Translate(30, float64(yPos+24))
+		detailOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+		text.Draw(screen, details, &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize,
+		}, detailOp)
+	}
+
+	// Draw instructions
+	instructions := "ENTER: Open | R: Rename | DEL/X: Delete | ESC: Back"
+	if d.game.renaming {
+		instructions = "Type a new name, ENTER to confirm, ESC to cancel"
+	}
+	instructOp := &text.DrawOptions{}
+	instructOp.GeoM.Translate(float64(startX), float64(listTop+maxVisible*entryHeight+20))
+	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	instructOp.PrimaryAlign = text.AlignCenter
+	text.Draw(screen, instructions, &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, instructOp)
+
+	d.drawMenuStatus(screen)
+}
+
+// drawSeedMenu shows the seed being typed and the difficulty it will be played at
+func (d *DrawHandler) drawSeedMenu(screen *ebiten.Image) {
+	startX := d.screenWidth / 2
+	startY := d.screenHeight / 3
+
+	titleOp := &text.DrawOptions{}
+	titleOp.GeoM.Translate(float64(startX), float64(startY-60))
+	titleOp.ColorScale.ScaleWithColor(color.Black)
+	titleOp.PrimaryAlign = text.AlignCenter
+	titleOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, "Play Seed", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize + 4,
+	}, titleOp)
+
+	// Draw the seed in an input box
+	vector.StrokeRect(
+		screen,
+		float32(startX-150),
+		float32(startY-20),
+		300,
+		40,
+		2,
+		color.RGBA{0, 0, 255, 255},
+		false,
+	)
+	seedOp := &text.DrawOptions{}
+	seedOp.GeoM.Translate(float64(startX), float64(startY))
+	seedOp.ColorScale.ScaleWithColor(color.Black)
+	seedOp.PrimaryAlign = text.AlignCenter
+	seedOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, string(d.game.seedBuffer)+"_", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize,
+	}, seedOp)
+
+	diffOp := &text.DrawOptions{}
+	diffOp.GeoM.Translate(float64(startX), float64(startY+60))
+	diffOp.ColorScale.ScaleWithColor(color.Black)
+	diffOp.PrimaryAlign = text.AlignCenter
+	diffOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, fmt.Sprintf("< %v >", d.game.difficulty), &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   diffFontSize,
+	}, diffOp)
+
+	removalOp := &text.DrawOptions{}
+	removalOp.GeoM.Translate(float64(startX), float64(startY+100))
+	removalOp.ColorScale.ScaleWithColor(color.Black)
+	removalOp.PrimaryAlign = text.AlignCenter
+	removalOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, fmt.Sprintf("Clues: %v", d.game.removal), &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize + 2,
+	}, removalOp)
+
+	instructOp := &text.DrawOptions{}
+	instructOp.GeoM.Translate(float64(startX), float64(startY+140))
+	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	instructOp.PrimaryAlign = text.AlignCenter
+	text.Draw(screen, "Type a seed, ←→ for difficulty, ↑↓ for clues, ENTER to play, ESC for menu", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, instructOp)
+
+	// Seeded games shuffle the loaded packs, so they only match with the same ones
+	if len(d.game.packPuzzles()) > 0 {
+		packOp := &text.DrawOptions{}
+		packOp.GeoM.Translate(float64(startX), float64(startY+160))
+		packOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+		packOp.PrimaryAlign = text.AlignCenter
+		text.Draw(screen, "Puzzles come from the loaded packs: share the packs with the seed", &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize,
+		}, packOp)
+	}
+
+	d.drawMenuStatus(screen)
+}
+
+// drawPackMenu lists the puzzle packs with how far through each one the player is
+func (d *DrawHandler) drawPackMenu(screen *ebiten.Image) {
+	startX := screenWidth / 2
+	listTop := 110
+	entryHeight := 44
+	maxVisible := 8
+
+	// Draw title
+	titleOp := &text.DrawOptions{}
+	titleOp.GeoM.Translate(float64(startX), float64(60))
+	titleOp.ColorScale.ScaleWithColor(color.Black)
+	titleOp.PrimaryAlign = text.AlignCenter
+	titleOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, "Puzzle Packs", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize + 4,
+	}, titleOp)
+
+	// Scroll so the selected pack is always visible
+	packs := d.game.packs
+	first := 0
+	if d.game.selected >= maxVisible {
+		first = d.game.selected - maxVisible + 1
+	}
+
+	for i := first; i < len(packs) && i < first+maxVisible; i++ {
+		pack := &packs[i]
+		yPos := listTop + (i-first)*entryHeight
+
+		if i == d.game.selected {
+			vector.DrawFilledRect(
+				screen,
+				20,
+				float32(yPos),
+				float32(d.screenWidth-40),
+				float32(entryHeight-4),
+				color.RGBA{0, 0, 255, 100},
+				false,
+			)
+		}
+
+		nameOp := &text.DrawOptions{}
+		nameOp.GeoM.Translate(30, float64(yPos+4))
+		nameOp.ColorScale.ScaleWithColor(color.Black)
+		text.Draw(screen, pack.Title, &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize + 4,
+		}, nameOp)
+
+		solved := d.game.progress.solvedCount(pack)
+		details := fmt.Sprintf("%d of %d solved", solved, len(pack.Puzzles))
+		if pack.Author != "" {
+			details = "by " + pack.Author + " | " + details
+		}
+		if solved < len(pack.Puzzles) {
+			details += " | next #" + pack.Puzzles[d.game.progress.nextPuzzle(pack)].ID
+		}
+		detailOp := &text.DrawOptions{}
+		detailOp.GeoM.Translate(30, float64(yPos+24))
+		detailOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+		text.Draw(screen, details, &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize,
+		}, detailOp)
+	}
+
+	// Draw instructions
+	instructOp := &text.DrawOptions{}
+	instructOp.GeoM.Translate(float64(startX), float64(listTop+maxVisible*entryHeight+20))
+	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	instructOp.PrimaryAlign = text.AlignCenter
+	text.Draw(screen, "ENTER: Play next puzzle | ESC: Back", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, instructOp)
+
+	d.drawMenuStatus(screen)
+}
+
+// drawStatsMenu shows the totals kept for each difficulty level
+func (d *DrawHandler) drawStatsMenu(screen *ebiten.Image) {
+	startX := screenWidth / 2
+	listTop := 110
+	entryHeight := 100
+
+	// Draw title
+	titleOp := &text.DrawOptions{}
+	titleOp.GeoM.Translate(float64(startX), float64(60))
+	titleOp.ColorScale.ScaleWithColor(color.Black)
+	titleOp.PrimaryAlign = text.AlignCenter
+	titleOp.SecondaryAlign = text.AlignCenter
+	text.Draw(screen, "Statistics", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   menuFontSize + 4,
+	}, titleOp)
+
+	stats := d.game.stats
+	if stats == nil {
+		emptyOp := &text.DrawOptions{}
+		emptyOp.GeoM.Translate(float64(startX), float64(listTop+40))
+		emptyOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+		emptyOp.PrimaryAlign = text.AlignCenter
+		text.Draw(screen, "Statistics are not available", &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize,
+		}, emptyOp)
+	}
+
+	for level := Easy; stats != nil && level <= Hard; level++ {
+		l := stats.level(level)
+		yPos := listTop + int(level)*entryHeight
+
+		nameOp := &text.DrawOptions{}
+		nameOp.GeoM.Translate(30, float64(yPos))
+		nameOp.ColorScale.ScaleWithColor(color.Black)
+		text.Draw(screen, level.String(), &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize + 6,
+		}, nameOp)
+
+		best, average := "-", "-"
+		if l.Completed > 0 {
+			best = formatDuration(l.BestTime)
+			average = formatDuration(l.AverageTime())
+		}
+		lines := []string{
+			fmt.Sprintf("Started: %d | Completed: %d", l.Started, l.Completed),
+			fmt.Sprintf("Best time: %s | Average time: %s", best, average),
+			fmt.Sprintf("Mistakes: %d | Hints used: %d", l.Mistakes, l.Hints),
+		}
+		for i, line := range lines {
+			lineOp := &text.DrawOptions{}
+			lineOp.GeoM.Translate(30, float64(yPos+28+i*18))
+			lineOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+			text.Draw(screen, line, &text.GoTextFace{
+				Source: d.fontSource,
+				Size:   normalFontSize,
+			}, lineOp)
+		}
+	}
+
+	// Draw the daily puzzle streak below the levels
+	if stats != nil {
+		today := dailyDate(time.Now())
+		status := "not solved yet"
+		if stats.Daily.doneOn(today) {
+			status = "solved"
+		}
+		dailyOp := &text.DrawOptions{}
+		dailyOp.GeoM.Translate(30, float64(listTop+3*entryHeight))
+		dailyOp.ColorScale.ScaleWithColor(color.Black)
+		text.Draw(screen, fmt.Sprintf("Daily: today's puzzle %s | Streak: %d | Best: %d | Solved: %d",
+			status, stats.Daily.currentStreak(today), stats.Daily.BestStreak, stats.Daily.Completed), &text.GoTextFace{
+			Source: d.fontSource,
+			Size:   normalFontSize,
+		}, dailyOp)
+	}
+
+	// Draw instructions
+	instructOp := &text.DrawOptions{}
+	instructOp.GeoM.Translate(float64(startX), float64(listTop+3*entryHeight+50))
+	instructOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	instructOp.PrimaryAlign = text.AlignCenter
+	text.Draw(screen, "ESC: Back", &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, instructOp)
+
+	d.drawMenuStatus(screen)
 }
 
 func (d *DrawHandler) drawGameMessages(screen *ebiten.Image) {
@@ -432,6 +965,13 @@ func (d *DrawHandler) drawStatusBar(screen *ebiten.Image) {
 		modeText = "Help Mode: ON"
 		modeColor = color.RGBA{0, 150, 0, 255} // Green when active
 	}
+	if d.game.specialEnterMode && d.game.centreMode {
+		modeText = "Help Mode: CENTRE"
+		modeColor = color.RGBA{0, 90, 200, 255}
+	}
+	if d.game.autoClear {
+		modeText += " | Auto Clear"
+	}
 
 	modeOp := &text.DrawOptions{}
 	modeOp.GeoM.Translate(float64(10), float64(d.statusTop+15))
@@ -444,15 +984,26 @@ func (d *DrawHandler) drawStatusBar(screen *ebiten.Image) {
 		Size:   normalFontSize,
 	}, modeOp)
 
-	// Draw help text
-	helpText := "H: Help Mode | N: Normal | P: Check Progress | Z/Backspace: Undo | ESC: Menu"
+	// Draw the clock on the right
+	clockOp := &text.DrawOptions{}
+	clockOp.GeoM.Translate(float64(d.screenWidth-10), float64(d.statusTop+15))
+	clockOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
+	clockOp.PrimaryAlign = text.AlignEnd
+	clockOp.SecondaryAlign = text.AlignStart
+
+	text.Draw(screen, "Time: "+formatDuration(d.game.clock.Elapsed()), &text.GoTextFace{
+		Source: d.fontSource,
+		Size:   normalFontSize,
+	}, clockOp)
+
+	// Draw a short help line, the full key list is on the pause screen
 	helpOp := &text.DrawOptions{}
 	helpOp.GeoM.Translate(float64(d.screenWidth/2), float64(d.screenHeight-20))
 	helpOp.ColorScale.ScaleWithColor(color.RGBA{100, 100, 100, 255})
 	helpOp.PrimaryAlign = text.AlignCenter
 	helpOp.SecondaryAlign = text.AlignEnd
 
-	text.Draw(screen, helpText, &text.GoTextFace{
+	text.Draw(screen, "Z: Undo | I: Hint | Tab: Pause and all keys | ESC: Menu", &text.GoTextFace{
 		Source: d.fontSource,
 		Size:   normalFontSize,
 	}, helpOp)
@@ -482,9 +1033,65 @@ func (d *DrawHandler) drawStatusBar(screen *ebiten.Image) {
 		op.PrimaryAlign = text.AlignCenter
 		op.SecondaryAlign = text.AlignCenter
 
-		text.Draw(screen, msg.text, &text.GoTextFace{
+		// Long messages such as hint explanations wrap onto a second line
+		face := &text.GoTextFace{
 			Source: d.fontSource,
-			Size:   normalFontSize + 2,
-		}, op)
+			Size:   normalFontSize,
+		}
+		op.LineSpacing = normalFontSize * 1.3
+		text.Draw(screen, wrapText(msg.text, face, float64(d.screenWidth-20)), face, op)
+	}
+}
+
+// wrapText breaks s into lines no wider than maxWidth when drawn with face
+func wrapText(s string, face text.Face, maxWidth float64) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if width, _ := text.Measure(candidate, face, 0); width > maxWidth && line != "" {
+			lines = append(lines, line)
+			line = word
+			continue
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// drawHint shades the cells involved in the current hint: the cells that
+// justify it, the cells losing candidates and the cell being filled
+func (d *DrawHandler) drawHint(screen *ebiten.Image) {
+	hint := d.game.hint
+	if hint == nil {
+		return
+	}
+
+	shade := func(row, col int, c color.RGBA) {
+		vector.DrawFilledRect(
+			screen,
+			float32(col*d.cellSize),
+			float32(d.gridTop+row*d.cellSize),
+			float32(d.cellSize),
+			float32(d.cellSize),
+			c,
+			false,
+		)
+	}
+
+	for _, cell := range hint.Cells {
+		shade(cell.Row, cell.Col, color.RGBA{255, 240, 150, 255}) // Yellow
+	}
+	for _, e := range hint.Eliminations {
+		shade(e.Row, e.Col, color.RGBA{255, 200, 200, 255}) // Light red
+	}
+	if hint.Value != 0 {
+		shade(hint.Cell.Row, hint.Cell.Col, color.RGBA{180, 240, 180, 255}) // Light green
 	}
 }
@@ -0,0 +1,165 @@
+package logic
+
+// Canonicalize maps a grid to the representative of its class under the
+// Sudoku symmetry group: every transform of the same puzzle gives the same
+// result, and puzzles that aren't equivalent give different ones. The
+// representative is the transform that reads smallest row by row once digits
+// are renumbered in order of first appearance, with empty cells lowest.
+func Canonicalize(p Puzzle) Puzzle {
+	c := &canonSearch{}
+	transposed := TransposeTransform().Apply(p)
+	for _, grid := range []Puzzle{p, transposed} {
+		c.grid = grid
+		for _, cols := range columnOrders {
+			c.cols = cols
+			c.search(0, [10]int{}, 1, [3]bool{}, -1, [9]bool{})
+		}
+	}
+	return c.best
+}
+
+// Equivalent reports whether two grids are the same puzzle in disguise
+func Equivalent(a, b Puzzle) bool {
+	return Canonicalize(a) == Canonicalize(b)
+}
+
+// Duplicate records a puzzle that is equivalent to an earlier one in a list
+type Duplicate struct {
+	Index    int // Position of the duplicate
+	Original int // Position of the first equivalent puzzle
+}
+
+// FindDuplicates returns every puzzle that is equivalent to an earlier one
+func FindDuplicates(puzzles []Puzzle) []Duplicate {
+	var dupes []Duplicate
+	first := make(map[Puzzle]int)
+	for i, p := range puzzles {
+		canon := Canonicalize(p)
+		if original, ok := first[canon]; ok {
+			dupes = append(dupes, Duplicate{Index: i, Original: original})
+			continue
+		}
+		first[canon] = i
+	}
+	return dupes
+}
+
+// columnOrders lists every column order allowed by the symmetry group
+var columnOrders = func() [][9]int {
+	var orders [][9]int
+	perms := [][3]int{{0, 1, 2}, {0, 2, 1}, {1, 0, 2}, {1, 2, 0}, {2, 0, 1}, {2, 1, 0}}
+	for _, stacks := range perms {
+		for _, a := range perms {
+			for _, b := range perms {
+				for _, c := range perms {
+					within := [3][3]int{a, b, c}
+					var order [9]int
+					for i := 0; i < 9; i++ {
+						order[i] = sourceLine(stacks, within, i)
+					}
+					orders = append(orders, order)
+				}
+			}
+		}
+	}
+	return orders
+}()
+
+// canonSearch picks rows one at a time for a fixed column order, keeping only
+// the choices that give the smallest renumbered row and pruning any branch
+// that already reads larger than the best grid found so far
+type canonSearch struct {
+	grid    Puzzle
+	cols    [9]int
+	out     Puzzle
+	best    Puzzle
+	hasBest bool
+}
+
+// search fills output row `row`. relabel maps original digits to their new
+// numbers and next is the next unused number. usedBands, band and usedRows
+// keep rows together in their bands.
+func (c *canonSearch) search(row int, relabel [10]int, next int, usedBands [3]bool, band int, usedRows [9]bool) {
+	if c.compareBest(row) > 0 {
+		return
+	}
+	if row == 9 {
+		if !c.hasBest || c.compareBest(9) < 0 {
+			c.best = c.out
+			c.hasBest = true
+		}
+		return
+	}
+
+	// Rows that may go next: any row of an unused band when starting a band,
+	// otherwise the remaining rows of the current band
+	type choice struct {
+		src     int
+		line    [9]int
+		relabel [10]int
+		next    int
+	}
+	var chosen []choice
+	for src := 0; src < 9; src++ {
+		if usedRows[src] || (row%3 == 0 && usedBands[src/3]) || (row%3 != 0 && src/3 != band) {
+			continue
+		}
+
+		// Renumber the row and keep it only if it is one of the smallest
+		ch := choice{src: src, relabel: relabel, next: next}
+		for i, col := range c.cols {
+			num := c.grid[src][col]
+			if num != 0 {
+				if ch.relabel[num] == 0 {
+					ch.relabel[num] = ch.next
+					ch.next++
+				}
+				num = ch.relabel[num]
+			}
+			ch.line[i] = num
+		}
+		if len(chosen) > 0 {
+			switch compareLines(ch.line, chosen[0].line) {
+			case 1:
+				continue
+			case -1:
+				chosen = chosen[:0]
+			}
+		}
+		chosen = append(chosen, ch)
+	}
+
+	for _, ch := range chosen {
+		c.out[row] = ch.line
+		bands, rows := usedBands, usedRows
+		bands[ch.src/3] = true
+		rows[ch.src] = true
+		c.search(row+1, ch.relabel, ch.next, bands, ch.src/3, rows)
+	}
+}
+
+// compareBest compares the first n output rows with the best grid so far
+func (c *canonSearch) compareBest(n int) int {
+	if !c.hasBest {
+		return -1
+	}
+	for row := 0; row < n; row++ {
+		if cmp := compareLines(c.out[row], c.best[row]); cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
+
+// compareLines orders two rows lexicographically, returning -1, 0 or 1
+func compareLines(a, b [9]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
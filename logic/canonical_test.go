@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test every disguise of a puzzle has the same canonical form
+func TestCanonicalize(t *testing.T) {
+	puzzle := parseGrid(t, testPuzzle)
+	want := Canonicalize(puzzle)
+	if again := Canonicalize(want); again != want {
+		t.Errorf("Canonicalize is not idempotent: %v then %v", want, again)
+	}
+	if _, err := Solve(want); err != nil {
+		t.Errorf("canonical form %v should still be solvable: %v", want, err)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		variant := RandomTransform(rng).Apply(puzzle)
+		if got := Canonicalize(variant); got != want {
+			t.Fatalf("Canonicalize(%v) = %v; want %v", variant, got, want)
+		}
+	}
+
+	// Moving one clue makes a different puzzle
+	other := puzzle
+	other[0][0], other[0][2] = 0, 5
+	if Equivalent(puzzle, other) {
+		t.Error("puzzles with different clue layouts should not be equivalent")
+	}
+}
+
+// Test duplicates are found however they are disguised
+func TestFindDuplicates(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	a, _ := generatePuzzle(1)
+	b, _ := generatePuzzle(2)
+	puzzles := []Puzzle{a, b, RandomTransform(rng).Apply(a), RandomTransform(rng).Apply(b), RandomTransform(rng).Apply(a)}
+
+	dupes := FindDuplicates(puzzles)
+	want := []Duplicate{{2, 0}, {3, 1}, {4, 0}}
+	if len(dupes) != len(want) {
+		t.Fatalf("FindDuplicates = %v; want %v", dupes, want)
+	}
+	for i := range want {
+		if dupes[i] != want[i] {
+			t.Errorf("FindDuplicates = %v; want %v", dupes, want)
+		}
+	}
+}
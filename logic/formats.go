@@ -0,0 +1,426 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is a text layout for puzzle files
+type Format int
+
+const (
+	FormatBlocks     Format = iota // Nine lines of digits per puzzle, 0 for empty cells
+	FormatLine                     // One puzzle per line as 81 characters, . or 0 for empty cells
+	FormatSDK                      // SadMan Sudoku .sdk: nine lines per puzzle, . for empty cells, # headers
+	FormatSS                       // SimpleSudoku .ss: rows split into boxes by | with dashed separator lines
+	FormatOpenSudoku               // OpenSudoku XML
+	FormatJSON                     // Our own JSON pack layout, carrying the pack's metadata
+)
+
+// ErrUnknownFormat is returned by ParseFormat for names it doesn't recognise
+var ErrUnknownFormat = errors.New("unknown puzzle format")
+
+// String returns the short name of the format, as accepted by ParseFormat
+func (f Format) String() string {
+	switch f {
+	case FormatBlocks:
+		return "blocks"
+	case FormatLine:
+		return "line"
+	case FormatSDK:
+		return "sdk"
+	case FormatSS:
+		return "ss"
+	case FormatOpenSudoku:
+		return "opensudoku"
+	case FormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseFormat looks a format up by its short name
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "blocks", "txt":
+		return FormatBlocks, nil
+	case "line", "81":
+		return FormatLine, nil
+	case "sdk":
+		return FormatSDK, nil
+	case "ss":
+		return FormatSS, nil
+	case "opensudoku", "xml":
+		return FormatOpenSudoku, nil
+	case "json":
+		return FormatJSON, nil
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+}
+
+// DetectFormat guesses the format of a puzzle file from its content
+func DetectFormat(data []byte) Format {
+	text := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+	if strings.HasPrefix(text, "<") {
+		return FormatOpenSudoku
+	}
+	if strings.HasPrefix(text, "{") {
+		return FormatJSON
+	}
+
+	format := FormatBlocks
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#") || strings.HasPrefix(line, "["):
+			format = FormatSDK // Headers only appear in .sdk files
+			continue
+		case strings.ContainsAny(line, "|!") || isSeparatorLine(line):
+			return FormatSS
+		case len(strings.Fields(line)[0]) >= 81:
+			return FormatLine
+		case strings.Contains(line, "."):
+			format = FormatSDK
+		}
+	}
+	return format
+}
+
+// ReadPuzzles reads puzzles in any of the supported formats, detecting which
+// one from the content
+func ReadPuzzles(r io.Reader) ([]Puzzle, error) {
+	pack, err := ReadPack(r)
+	if err != nil {
+		return nil, err
+	}
+	return pack.Grids(), nil
+}
+
+// ReadPuzzlesFormat reads puzzles written in the given format
+func ReadPuzzlesFormat(r io.Reader, f Format) ([]Puzzle, error) {
+	pack, err := ReadPackFormat(r, f)
+	if err != nil {
+		return nil, err
+	}
+	return pack.Grids(), nil
+}
+
+// WritePuzzles writes the puzzles in the given format
+func WritePuzzles(w io.Writer, f Format, puzzles []Puzzle) error {
+	return WritePack(w, f, NewPack(puzzles))
+}
+
+func decodePack(data []byte, f Format) (*Pack, error) {
+	var puzzles []Puzzle
+	var err error
+	switch f {
+	case FormatBlocks:
+		puzzles, err = readGrids(data, gridRow)
+	case FormatSDK:
+		puzzles, err = readGrids(data, gridRow)
+		if err != nil {
+			return nil, err
+		}
+		pack := NewPack(puzzles)
+		readSDKHeaders(data, pack)
+		return pack, nil
+	case FormatSS:
+		puzzles, err = readGrids(data, ssRow)
+	case FormatLine:
+		puzzles, err = readLines(data)
+	case FormatOpenSudoku:
+		return readOpenSudoku(data)
+	case FormatJSON:
+		return readJSONPack(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewPack(puzzles), nil
+}
+
+// WritePack writes the pack in the given format, keeping as much of its
+// metadata as the format has room for
+func WritePack(w io.Writer, f Format, pack *Pack) error {
+	switch f {
+	case FormatOpenSudoku:
+		return writeOpenSudoku(w, pack)
+	case FormatJSON:
+		return writeJSONPack(w, pack)
+	}
+
+	bw := bufio.NewWriter(w)
+	if f == FormatSDK {
+		writeSDKHeaders(bw, pack)
+	}
+	for i, p := range pack.Puzzles {
+		switch f {
+		case FormatLine:
+			bw.WriteString(p.Puzzle.String())
+			bw.WriteByte('\n')
+		case FormatBlocks, FormatSDK, FormatSS:
+			if i > 0 {
+				bw.WriteByte('\n') // Blank line between puzzles
+			}
+			writeGrid(bw, f, p.Puzzle)
+		default:
+			return ErrUnknownFormat
+		}
+	}
+	return bw.Flush()
+}
+
+// String returns the puzzle as 81 characters, row by row, with . for empty cells
+func (p Puzzle) String() string {
+	var b strings.Builder
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			b.WriteByte(cellChar(p[row][col], '.'))
+		}
+	}
+	return b.String()
+}
+
+// ParsePuzzle reads a puzzle from 81 characters, using . or 0 for empty cells
+func ParsePuzzle(s string) (Puzzle, error) {
+	var p Puzzle
+	if len(s) != 81 {
+		return p, fmt.Errorf("invalid puzzle length: %d, want 81", len(s))
+	}
+	for i, char := range s {
+		num, ok := cellValue(char)
+		if !ok {
+			return p, fmt.Errorf("invalid character in puzzle: %q", char)
+		}
+		p[i/9][i%9] = num
+	}
+	return p, nil
+}
+
+// cellValue turns a cell character into its digit, 0 for an empty cell
+func cellValue(char rune) (int, bool) {
+	switch {
+	case char >= '0' && char <= '9':
+		return int(char - '0'), true
+	case char == '.':
+		return 0, true
+	}
+	return 0, false
+}
+
+// cellChar writes a digit as a character, using empty for 0
+func cellChar(num int, empty byte) byte {
+	if num == 0 {
+		return empty
+	}
+	return byte('0' + num)
+}
+
+// isSeparatorLine reports whether the line is one of the dashed box borders
+// in .ss files
+func isSeparatorLine(line string) bool {
+	return line != "" && strings.Trim(line, "-+*|!") == ""
+}
+
+// gridRow returns the cells of a row in the blocks and .sdk formats, skipping
+// headers
+func gridRow(line string) (string, bool) {
+	if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+		return "", false
+	}
+	return line, true
+}
+
+// ssRow returns the cells of a row in the .ss format, skipping box borders
+func ssRow(line string) (string, bool) {
+	if isSeparatorLine(line) {
+		return "", false
+	}
+	return strings.NewReplacer("|", "", "!", "", " ", "").Replace(line), true
+}
+
+// readGrids reads puzzles laid out as nine rows of nine cells. Blank lines
+// between puzzles are optional.
+func readGrids(data []byte, rowText func(line string) (string, bool)) ([]Puzzle, error) {
+	var puzzles []Puzzle
+	var currentPuzzle Puzzle
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	row := 0
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		line, ok := rowText(line)
+		if !ok {
+			continue
+		}
+
+		if len(line) != 9 {
+			return nil, fmt.Errorf("invalid row length in puzzle: %v. The row has %v", line, len(line))
+		}
+		for col, char := range line {
+			num, ok := cellValue(char)
+			if !ok {
+				return nil, fmt.Errorf("invalid character in puzzle: %q", char)
+			}
+			currentPuzzle[row][col] = num
+		}
+
+		row++
+		if row == 9 {
+			puzzles = append(puzzles, currentPuzzle)
+			row = 0
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	if row != 0 {
+		return nil, fmt.Errorf("incomplete puzzle: only %d rows", row)
+	}
+	return puzzles, nil
+}
+
+// readLines reads one puzzle per line. Anything after the 81 cells, such as a
+// rating, is ignored, as are lines starting with #.
+func readLines(data []byte) ([]Puzzle, error) {
+	var puzzles []Puzzle
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		p, err := ParsePuzzle(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		puzzles = append(puzzles, p)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	return puzzles, nil
+}
+
+// writeGrid writes one puzzle as nine rows in the blocks, .sdk or .ss layout
+func writeGrid(w *bufio.Writer, f Format, p Puzzle) {
+	empty := byte('.')
+	if f == FormatBlocks {
+		empty = '0'
+	}
+	for row := 0; row < 9; row++ {
+		if f == FormatSS && row > 0 && row%3 == 0 {
+			w.WriteString("-----------\n")
+		}
+		for col := 0; col < 9; col++ {
+			if f == FormatSS && col > 0 && col%3 == 0 {
+				w.WriteByte('|')
+			}
+			w.WriteByte(cellChar(p[row][col], empty))
+		}
+		w.WriteByte('\n')
+	}
+}
+
+// readSDKHeaders picks the pack details out of the # header lines of an .sdk
+// file: #A is the author, #D the description and #L the level
+func readSDKHeaders(data []byte, pack *Pack) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) < 2 || line[0] != '#' {
+			continue
+		}
+		value := strings.TrimSpace(line[2:])
+		switch line[1] {
+		case 'A':
+			pack.Author = value
+		case 'D':
+			pack.Title = value
+		case 'L':
+			for i := range pack.Puzzles {
+				pack.Puzzles[i].Difficulty = value
+			}
+		}
+	}
+}
+
+// writeSDKHeaders writes the pack details as .sdk header lines
+func writeSDKHeaders(w *bufio.Writer, pack *Pack) {
+	if pack.Author != "" {
+		fmt.Fprintf(w, "#A%s\n", pack.Author)
+	}
+	if pack.Title != "" {
+		fmt.Fprintf(w, "#D%s\n", pack.Title)
+	}
+	if len(pack.Puzzles) == 1 && pack.Puzzles[0].Difficulty != "" {
+		fmt.Fprintf(w, "#L%s\n", pack.Puzzles[0].Difficulty)
+	}
+}
+
+// openSudokuFile is the XML layout of an OpenSudoku puzzle collection
+type openSudokuFile struct {
+	XMLName xml.Name         `xml:"opensudoku"`
+	Name    string           `xml:"name,omitempty"`
+	Author  string           `xml:"author,omitempty"`
+	Games   []openSudokuGame `xml:"game"`
+}
+
+type openSudokuGame struct {
+	Data string `xml:"data,attr"`
+}
+
+func readOpenSudoku(data []byte) (*Pack, error) {
+	var file openSudokuFile
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenSudoku XML: %v", err)
+	}
+	puzzles := make([]Puzzle, 0, len(file.Games))
+	for i, game := range file.Games {
+		p, err := ParsePuzzle(strings.TrimSpace(game.Data))
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %v", i+1, err)
+		}
+		puzzles = append(puzzles, p)
+	}
+	pack := NewPack(puzzles)
+	pack.Title = strings.TrimSpace(file.Name)
+	pack.Author = strings.TrimSpace(file.Author)
+	return pack, nil
+}
+
+func writeOpenSudoku(w io.Writer, pack *Pack) error {
+	file := openSudokuFile{
+		Name:   pack.Title,
+		Author: pack.Author,
+		Games:  make([]openSudokuGame, len(pack.Puzzles)),
+	}
+	for i, p := range pack.Puzzles {
+		file.Games[i].Data = strings.ReplaceAll(p.Puzzle.String(), ".", "0")
+	}
+	data, err := xml.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenSudoku XML: %v", err)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,132 @@
+package logic
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Test reading each format in the layout other programs write it
+func TestReadPuzzlesFormats(t *testing.T) {
+	want := parseGrid(t, testPuzzle)
+
+	tests := []struct {
+		name   string
+		input  string
+		format Format
+	}{
+		{"blocks", "530070000\n600195000\n098000060\n800060003\n400803001\n700020006\n060000280\n000419005\n000080079\n", FormatBlocks},
+		{"line with dots and a rating", "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79  2.3\n", FormatLine},
+		{"line with zeros", testPuzzle + "\n", FormatLine},
+		{"sdk", "#AAsh\n#DA test puzzle\n53..7....\n6..195...\n.98....6.\n8...6...3\n4..8.3..1\n7...2...6\n.6....28.\n...419..5\n....8..79\n", FormatSDK},
+		{"ss", "53.|.7.|...\n6..|195|...\n.98|...|.6.\n-----------\n8..|.6.|..3\n4..|8.3|..1\n7..|.2.|..6\n-----------\n.6.|...|28.\n...|419|..5\n...|.8.|.79\n", FormatSS},
+		{"opensudoku", `<?xml version="1.0" encoding="UTF-8"?>
+<opensudoku>
+  <name>Test</name>
+  <game data="` + testPuzzle + `" />
+</opensudoku>`, FormatOpenSudoku},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if f := DetectFormat([]byte(tt.input)); f != tt.format {
+				t.Errorf("DetectFormat = %v; want %v", f, tt.format)
+			}
+			puzzles, err := ReadPuzzles(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("ReadPuzzles returned error: %v", err)
+			}
+			if len(puzzles) != 1 || puzzles[0] != want {
+				t.Errorf("ReadPuzzles = %v; want [%v]", puzzles, want)
+			}
+		})
+	}
+}
+
+// Test every writer produces something its reader accepts
+func TestWritePuzzlesRoundTrip(t *testing.T) {
+	puzzles := []Puzzle{parseGrid(t, testPuzzle), parseGrid(t, testAnswer)}
+	for _, f := range []Format{FormatBlocks, FormatLine, FormatSDK, FormatSS, FormatOpenSudoku, FormatJSON} {
+		var buf bytes.Buffer
+		if err := WritePuzzles(&buf, f, puzzles); err != nil {
+			t.Fatalf("WritePuzzles(%v) returned error: %v", f, err)
+		}
+		if detected := DetectFormat(buf.Bytes()); detected != f {
+			t.Errorf("DetectFormat(%v output) = %v", f, detected)
+		}
+		got, err := ReadPuzzles(&buf)
+		if err != nil {
+			t.Fatalf("ReadPuzzles(%v output) returned error: %v", f, err)
+		}
+		if !reflect.DeepEqual(got, puzzles) {
+			t.Errorf("%v round trip = %v; want %v", f, got, puzzles)
+		}
+	}
+}
+
+// Test malformed input is rejected
+func TestReadPuzzlesErrors(t *testing.T) {
+	for _, input := range []string{
+		"53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..7x",
+		"530070000\n600195000\n098000060\n",
+		"53007000\n",
+		"<opensudoku><game data=\"123\"/></opensudoku>",
+	} {
+		if _, err := ReadPuzzles(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadPuzzles(%q) should fail", input)
+		}
+	}
+
+	if _, err := ParseFormat("pdf"); err == nil {
+		t.Error("ParseFormat(pdf) should fail")
+	}
+}
+
+// Test pack metadata survives the formats that can hold it
+func TestPackMetadata(t *testing.T) {
+	solution := parseGrid(t, testAnswer)
+	pack := &Pack{
+		Title:  "Starter",
+		Author: "Ash",
+		Puzzles: []PackPuzzle{
+			{ID: "a1", Puzzle: parseGrid(t, testPuzzle), Difficulty: "easy", Solution: &solution},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePack(&buf, FormatJSON, pack); err != nil {
+		t.Fatalf("WritePack returned error: %v", err)
+	}
+	got, err := ReadPack(&buf)
+	if err != nil {
+		t.Fatalf("ReadPack returned error: %v", err)
+	}
+	if !reflect.DeepEqual(got, pack) {
+		t.Errorf("JSON round trip = %+v; want %+v", got, pack)
+	}
+
+	for _, f := range []Format{FormatSDK, FormatOpenSudoku} {
+		buf.Reset()
+		if err := WritePack(&buf, f, pack); err != nil {
+			t.Fatalf("WritePack(%v) returned error: %v", f, err)
+		}
+		got, err := ReadPack(&buf)
+		if err != nil {
+			t.Fatalf("ReadPack(%v output) returned error: %v", f, err)
+		}
+		if got.Title != "Starter" || got.Author != "Ash" || got.Puzzles[0].ID != "1" {
+			t.Errorf("%v round trip = %+v; want the title, author and a numbered puzzle", f, got)
+		}
+	}
+
+	// A solution that doesn't fit the clues is refused
+	bad := `{"puzzles": [{"puzzle": "` + testPuzzle + `", "solution": "` + strings.Replace(testAnswer, "5", "6", 1) + `"}]}`
+	if _, err := ReadPack(strings.NewReader(bad)); err == nil {
+		t.Error("ReadPack should refuse a solution that contradicts the puzzle")
+	}
+	dup := `{"puzzles": [{"id": "x", "puzzle": "` + testPuzzle + `"}, {"id": "x", "puzzle": "` + testPuzzle + `"}]}`
+	if _, err := ReadPack(strings.NewReader(dup)); err == nil {
+		t.Error("ReadPack should refuse duplicate ids")
+	}
+}
@@ -0,0 +1,176 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// GenerateSolvedGrid builds a random, complete and valid grid by running the
+// solver over an empty board with the digit order shuffled at every step.
+// A nil rng falls back to the global math/rand source.
+func GenerateSolvedGrid(rng *rand.Rand) Puzzle {
+	rng = randSource(rng)
+
+	s, _ := newSolver(Puzzle{}) // An empty grid can never conflict
+	s.rng = rng
+
+	// Seed the three diagonal boxes first: they don't share any row, column
+	// or box so any permutation is valid and the search has less to do.
+	for box := 0; box < 9; box += 4 {
+		startRow, startCol := (box/3)*3, (box%3)*3
+		for i, num := range rng.Perm(9) {
+			s.place(startRow+i/3, startCol+i%3, num+1)
+		}
+	}
+
+	s.search()
+	return s.first
+}
+
+// randSource returns rng, or a source seeded from the global math/rand one
+// when rng is nil
+func randSource(rng *rand.Rand) *rand.Rand {
+	if rng == nil {
+		return rand.New(rand.NewSource(rand.Int63()))
+	}
+	return rng
+}
+
+// ErrNoPuzzle is returned when none of a Generator's candidates had a unique solution
+var ErrNoPuzzle = errors.New("no candidate puzzle had a unique solution")
+
+// Spec describes the puzzles a Generator should build
+type Spec struct {
+	Sources  []Puzzle          // Grids to shuffle into candidates, nil to generate fresh grids
+	Removal  RemovalStrategy   // How clues are taken out of each candidate
+	Clues    int               // Difficulty passed on to RemoveClues
+	Accept   func(Rating) bool // Reports whether a rating hits the target, nil accepts any
+	Attempts int               // Candidates tried before settling for the last one
+}
+
+// Generator builds puzzles that hit a target rating, trying candidates on a
+// pool of goroutines. Each candidate is built from its own seed derived from
+// the one asked for and the first acceptable one in attempt order wins, so
+// the result only depends on the seed and the Spec, never on scheduling.
+type Generator struct {
+	Workers int // Goroutines to use, runtime.NumCPU() when 0
+}
+
+// candidate is one attempt at a puzzle
+type candidate struct {
+	index    int
+	puzzle   Puzzle
+	rating   Rating
+	unique   bool
+	accepted bool
+}
+
+func (g Generator) workers() int {
+	if g.Workers > 0 {
+		return g.Workers
+	}
+	return runtime.NumCPU()
+}
+
+// Generate builds a puzzle from the seed following the spec. It returns the
+// first candidate the spec accepts, or the last uniquely solvable one when
+// none of the attempts hit the target. Cancelling ctx stops the workers and
+// returns its error.
+func (g Generator) Generate(ctx context.Context, seed int64, spec Spec) (Puzzle, Rating, error) {
+	if err := ctx.Err(); err != nil {
+		return Puzzle{}, Rating{}, err
+	}
+	attempts := max(spec.Attempts, 1)
+	seeds := make([]int64, attempts)
+	base := rand.New(rand.NewSource(seed))
+	for i := range seeds {
+		seeds[i] = base.Int63()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := 0; i < attempts; i++ {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	results := make(chan candidate)
+	var wg sync.WaitGroup
+	for w := 0; w < min(g.workers(), attempts); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					return
+				}
+				c := spec.candidate(rand.New(rand.NewSource(seeds[i])))
+				c.index = i
+				select {
+				case results <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Candidates finish out of order, so wait until every earlier attempt
+	// is known to have missed before taking one
+	done := make([]*candidate, attempts)
+	fallback := -1
+	next := 0
+	for c := range results {
+		done[c.index] = &c
+		for next < attempts && done[next] != nil {
+			if done[next].accepted {
+				return done[next].puzzle, done[next].rating, nil
+			}
+			if done[next].unique {
+				fallback = next
+			}
+			next++
+		}
+		if next == attempts {
+			if fallback < 0 {
+				return Puzzle{}, Rating{}, ErrNoPuzzle
+			}
+			return done[fallback].puzzle, done[fallback].rating, nil
+		}
+	}
+	return Puzzle{}, Rating{}, ctx.Err()
+}
+
+// candidate builds and grades one puzzle from rng
+func (s Spec) candidate(rng *rand.Rand) candidate {
+	var grid [9][9]int
+	if len(s.Sources) > 0 {
+		grid = GetRandomPuzzle(rng, s.Sources)
+		ShuffleAsh(rng, &grid)
+	} else {
+		grid = GenerateSolvedGrid(rng)
+	}
+	RemoveClues(rng, &grid, s.Removal, s.Clues)
+
+	c := candidate{puzzle: grid}
+	if c.unique = HasUniqueSolution(grid); c.unique {
+		c.rating = Grade(grid)
+		c.accepted = s.Accept == nil || s.Accept(c.rating)
+	}
+	return c
+}
@@ -0,0 +1,866 @@
+package logic
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Technique is a human solving technique, ordered from easiest to hardest
+type Technique int
+
+const (
+	HiddenSingle Technique = iota
+	NakedSingle
+	PointingPair
+	BoxLineReduction
+	NakedPair
+	HiddenPair
+	NakedTriple
+	HiddenTriple
+	XWing
+	Swordfish
+	XYWing
+	XYChain
+	Backtracking // No technique applies, the solver has to guess
+)
+
+// String returns the display name of the technique
+func (t Technique) String() string {
+	switch t {
+	case HiddenSingle:
+		return "Hidden single"
+	case NakedSingle:
+		return "Naked single"
+	case PointingPair:
+		return "Pointing pair"
+	case BoxLineReduction:
+		return "Box/line reduction"
+	case NakedPair:
+		return "Naked pair"
+	case HiddenPair:
+		return "Hidden pair"
+	case NakedTriple:
+		return "Naked triple"
+	case HiddenTriple:
+		return "Hidden triple"
+	case XWing:
+		return "X-Wing"
+	case Swordfish:
+		return "Swordfish"
+	case XYWing:
+		return "XY-Wing"
+	case XYChain:
+		return "XY-Chain"
+	case Backtracking:
+		return "Backtracking"
+	default:
+		return "Unknown"
+	}
+}
+
+// Score is the number of points a single use of the technique adds to a rating
+func (t Technique) Score() int {
+	switch t {
+	case HiddenSingle:
+		return 1
+	case NakedSingle:
+		return 2
+	case PointingPair, BoxLineReduction:
+		return 5
+	case NakedPair:
+		return 6
+	case HiddenPair:
+		return 8
+	case NakedTriple:
+		return 10
+	case HiddenTriple:
+		return 12
+	case XWing:
+		return 20
+	case Swordfish, XYWing:
+		return 30
+	case XYChain:
+		return 40
+	default:
+		return 100
+	}
+}
+
+// Rating describes how hard a puzzle is to solve by hand
+type Rating struct {
+	Hardest Technique // Hardest technique the solve needed
+	Score   int       // Sum of the technique scores over the whole solve
+	Steps   int       // Number of deductions made
+}
+
+// Cell identifies a square on the grid
+type Cell struct {
+	Row, Col int
+}
+
+// Candidate is a digit that may still go in a cell
+type Candidate struct {
+	Row, Col int
+	Value    int
+}
+
+// UnitKind says whether a unit is a row, a column or a box
+type UnitKind int
+
+const (
+	RowUnit UnitKind = iota
+	ColUnit
+	BoxUnit
+)
+
+// Unit is one of the 27 rows, columns and boxes that must hold every digit
+type Unit struct {
+	Kind  UnitKind
+	Index int // 0-8
+}
+
+// String returns a one-based description such as "row 3" or "box 2"
+func (u Unit) String() string {
+	switch u.Kind {
+	case RowUnit:
+		return fmt.Sprintf("row %d", u.Index+1)
+	case ColUnit:
+		return fmt.Sprintf("column %d", u.Index+1)
+	default:
+		return fmt.Sprintf("box %d", u.Index+1)
+	}
+}
+
+// Cells lists the nine cells of the unit
+func (u Unit) Cells() [9]Cell {
+	var cells [9]Cell
+	for i := 0; i < 9; i++ {
+		switch u.Kind {
+		case RowUnit:
+			cells[i] = Cell{u.Index, i}
+		case ColUnit:
+			cells[i] = Cell{i, u.Index}
+		default:
+			cells[i] = Cell{(u.Index/3)*3 + i/3, (u.Index%3)*3 + i%3}
+		}
+	}
+	return cells
+}
+
+// allUnits holds the 9 rows, then the 9 columns, then the 9 boxes
+var allUnits = func() [27]Unit {
+	var units [27]Unit
+	for i := 0; i < 9; i++ {
+		units[i] = Unit{RowUnit, i}
+		units[9+i] = Unit{ColUnit, i}
+		units[18+i] = Unit{BoxUnit, i}
+	}
+	return units
+}()
+
+// sees reports whether two different cells share a row, column or box
+func sees(a, b Cell) bool {
+	if a == b {
+		return false
+	}
+	return a.Row == b.Row || a.Col == b.Col || boxIndex(a.Row, a.Col) == boxIndex(b.Row, b.Col)
+}
+
+// Candidates holds the pencil marks of every cell as a bit mask, bit n set
+// meaning n may still go in the cell. Filled cells have no candidates.
+type Candidates [9][9]uint16
+
+// NewCandidates computes the digits each empty cell can take given the numbers already placed
+func NewCandidates(p Puzzle) Candidates {
+	var c Candidates
+	var rows, cols, boxes [9]uint16
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if num := p[row][col]; num >= 1 && num <= 9 {
+				bit := uint16(1) << num
+				rows[row] |= bit
+				cols[col] |= bit
+				boxes[boxIndex(row, col)] |= bit
+			}
+		}
+	}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if p[row][col] == 0 {
+				c[row][col] = allDigits &^ (rows[row] | cols[col] | boxes[boxIndex(row, col)])
+			}
+		}
+	}
+	return c
+}
+
+// Has reports whether num is a candidate for the cell
+func (c *Candidates) Has(row, col, num int) bool {
+	return c[row][col]&(1<<num) != 0
+}
+
+// Digits lists the candidates of the cell in ascending order
+func (c *Candidates) Digits(row, col int) []int {
+	return maskDigits(c[row][col])
+}
+
+// Step is a single deduction made by one of the solving techniques
+type Step struct {
+	Technique    Technique
+	Cell         Cell        // Cell being filled, only meaningful when Value is set
+	Value        int         // Digit placed, 0 when the step only removes candidates
+	Eliminations []Candidate // Candidates the step removes
+	Cells        []Cell      // Cells that justify the deduction
+	Digits       []int       // Digits the pattern is built on
+	Unit         *Unit       // Unit the pattern lives in, if there is a single one
+}
+
+// board is the working state of a human-style solve
+type board struct {
+	grid Puzzle
+	cand Candidates
+}
+
+// apply carries out a step, placing its digit and removing its eliminations
+func (b *board) apply(s *Step) {
+	if s.Value != 0 {
+		row, col := s.Cell.Row, s.Cell.Col
+		b.grid[row][col] = s.Value
+		b.cand[row][col] = 0
+		bit := uint16(1) << s.Value
+		for r := 0; r < 9; r++ {
+			for c := 0; c < 9; c++ {
+				if sees(s.Cell, Cell{r, c}) {
+					b.cand[r][c] &^= bit
+				}
+			}
+		}
+	}
+	for _, e := range s.Eliminations {
+		b.cand[e.Row][e.Col] &^= 1 << e.Value
+	}
+}
+
+// solved reports whether every cell has been filled
+func (b *board) solved() bool {
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if b.grid[row][col] == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// techniques is the ladder the grader climbs, easiest first
+var techniques = []struct {
+	technique Technique
+	find      func(b *board) *Step
+}{
+	{HiddenSingle, findHiddenSingle},
+	{NakedSingle, findNakedSingle},
+	{PointingPair, findPointing},
+	{BoxLineReduction, findBoxLine},
+	{NakedPair, func(b *board) *Step { return findNakedSubset(b, 2) }},
+	{HiddenPair, func(b *board) *Step { return findHiddenSubset(b, 2) }},
+	{NakedTriple, func(b *board) *Step { return findNakedSubset(b, 3) }},
+	{HiddenTriple, func(b *board) *Step { return findHiddenSubset(b, 3) }},
+	{XWing, func(b *board) *Step { return findFish(b, 2) }},
+	{Swordfish, func(b *board) *Step { return findFish(b, 3) }},
+	{XYWing, findXYWing},
+	{XYChain, findXYChain},
+}
+
+// nextLogicalStep returns the easiest deduction available on the board, or nil if none applies
+func nextLogicalStep(b *board) *Step {
+	for _, t := range techniques {
+		if s := t.find(b); s != nil {
+			return s
+		}
+	}
+	return nil
+}
+
+// Grade solves the puzzle with the technique ladder, always using the easiest
+// deduction available, and reports the hardest technique needed along with a
+// score. When the ladder gets stuck a cell is filled from the real solution
+// and counted as Backtracking. Puzzles with no solution rate as Backtracking.
+func Grade(p Puzzle) Rating {
+	solution, err := Solve(p)
+	if err != nil {
+		return Rating{Hardest: Backtracking, Score: Backtracking.Score()}
+	}
+
+	b := &board{grid: p, cand: NewCandidates(p)}
+	var rating Rating
+	for !b.solved() {
+		s := nextLogicalStep(b)
+		if s == nil {
+			s = guessStep(b, solution)
+		}
+		b.apply(s)
+		rating.Steps++
+		rating.Score += s.Technique.Score()
+		if s.Technique > rating.Hardest {
+			rating.Hardest = s.Technique
+		}
+	}
+	return rating
+}
+
+// guessStep fills the empty cell with the fewest candidates from the solution
+func guessStep(b *board, solution Puzzle) *Step {
+	best := Cell{-1, -1}
+	bestCount := 10
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if b.grid[row][col] != 0 {
+				continue
+			}
+			if n := bits.OnesCount16(b.cand[row][col]); n < bestCount {
+				best, bestCount = Cell{row, col}, n
+			}
+		}
+	}
+	return &Step{
+		Technique: Backtracking,
+		Cell:      best,
+		Value:     solution[best.Row][best.Col],
+	}
+}
+
+// findHiddenSingle looks for a digit that fits in only one cell of a unit
+func findHiddenSingle(b *board) *Step {
+	for i := range allUnits {
+		unit := allUnits[i]
+		cells := unit.Cells()
+		for num := 1; num <= 9; num++ {
+			var only Cell
+			count := 0
+			for _, c := range cells {
+				if b.grid[c.Row][c.Col] == num {
+					count = -1
+					break
+				}
+				if b.cand.Has(c.Row, c.Col, num) {
+					only = c
+					count++
+				}
+			}
+			if count != 1 {
+				continue
+			}
+			var others []Cell
+			for _, c := range cells {
+				if c != only {
+					others = append(others, c)
+				}
+			}
+			return &Step{
+				Technique: HiddenSingle,
+				Cell:      only,
+				Value:     num,
+				Cells:     others,
+				Digits:    []int{num},
+				Unit:      &unit,
+			}
+		}
+	}
+	return nil
+}
+
+// findNakedSingle looks for an empty cell with a single candidate left
+func findNakedSingle(b *board) *Step {
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if b.grid[row][col] != 0 || bits.OnesCount16(b.cand[row][col]) != 1 {
+				continue
+			}
+			num := bits.TrailingZeros16(b.cand[row][col])
+			target := Cell{row, col}
+
+			// The filled peers are what rules out every other digit
+			var peers []Cell
+			for r := 0; r < 9; r++ {
+				for c := 0; c < 9; c++ {
+					if b.grid[r][c] != 0 && sees(target, Cell{r, c}) {
+						peers = append(peers, Cell{r, c})
+					}
+				}
+			}
+			return &Step{
+				Technique: NakedSingle,
+				Cell:      target,
+				Value:     num,
+				Cells:     peers,
+				Digits:    []int{num},
+			}
+		}
+	}
+	return nil
+}
+
+// cellsWith lists the cells of the unit that have num as a candidate
+func (b *board) cellsWith(unit Unit, num int) []Cell {
+	var found []Cell
+	for _, c := range unit.Cells() {
+		if b.cand.Has(c.Row, c.Col, num) {
+			found = append(found, c)
+		}
+	}
+	return found
+}
+
+// eliminate collects the candidates of num in the unit, skipping the excluded cells
+func (b *board) eliminate(unit Unit, num int, exclude []Cell) []Candidate {
+	var elims []Candidate
+	for _, c := range unit.Cells() {
+		if containsCell(exclude, c) || !b.cand.Has(c.Row, c.Col, num) {
+			continue
+		}
+		elims = append(elims, Candidate{c.Row, c.Col, num})
+	}
+	return elims
+}
+
+func containsCell(cells []Cell, c Cell) bool {
+	for _, other := range cells {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
+
+// findPointing looks for a digit confined to one row or column inside a box,
+// which removes it from the rest of that row or column
+func findPointing(b *board) *Step {
+	for box := 0; box < 9; box++ {
+		boxUnit := Unit{BoxUnit, box}
+		for num := 1; num <= 9; num++ {
+			cells := b.cellsWith(boxUnit, num)
+			if len(cells) < 2 {
+				continue
+			}
+			for _, line := range sharedLines(cells) {
+				if elims := b.eliminate(line, num, cells); len(elims) > 0 {
+					return &Step{
+						Technique:    PointingPair,
+						Eliminations: elims,
+						Cells:        cells,
+						Digits:       []int{num},
+						Unit:         &boxUnit,
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// findBoxLine looks for a digit confined to one box inside a row or column,
+// which removes it from the rest of that box
+func findBoxLine(b *board) *Step {
+	for i := 0; i < 18; i++ {
+		line := allUnits[i]
+		for num := 1; num <= 9; num++ {
+			cells := b.cellsWith(line, num)
+			if len(cells) < 2 {
+				continue
+			}
+			box := boxIndex(cells[0].Row, cells[0].Col)
+			sameBox := true
+			for _, c := range cells[1:] {
+				if boxIndex(c.Row, c.Col) != box {
+					sameBox = false
+					break
+				}
+			}
+			if !sameBox {
+				continue
+			}
+			if elims := b.eliminate(Unit{BoxUnit, box}, num, cells); len(elims) > 0 {
+				return &Step{
+					Technique:    BoxLineReduction,
+					Eliminations: elims,
+					Cells:        cells,
+					Digits:       []int{num},
+					Unit:         &line,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sharedLines returns the row and/or column every cell lies in
+func sharedLines(cells []Cell) []Unit {
+	sameRow, sameCol := true, true
+	for _, c := range cells[1:] {
+		if c.Row != cells[0].Row {
+			sameRow = false
+		}
+		if c.Col != cells[0].Col {
+			sameCol = false
+		}
+	}
+	var lines []Unit
+	if sameRow {
+		lines = append(lines, Unit{RowUnit, cells[0].Row})
+	}
+	if sameCol {
+		lines = append(lines, Unit{ColUnit, cells[0].Col})
+	}
+	return lines
+}
+
+// combinations calls fn with every size-k subset of items, stopping when fn returns true
+func combinations(items []int, k int, fn func(subset []int) bool) bool {
+	subset := make([]int, 0, k)
+	var rec func(start int) bool
+	rec = func(start int) bool {
+		if len(subset) == k {
+			return fn(subset)
+		}
+		for i := start; i <= len(items)-(k-len(subset)); i++ {
+			subset = append(subset, items[i])
+			if rec(i + 1) {
+				return true
+			}
+			subset = subset[:len(subset)-1]
+		}
+		return false
+	}
+	return rec(0)
+}
+
+// findNakedSubset looks for n cells in a unit sharing exactly n candidates,
+// which can then be removed from the rest of the unit
+func findNakedSubset(b *board, n int) *Step {
+	technique := NakedPair
+	if n == 3 {
+		technique = NakedTriple
+	}
+
+	for i := range allUnits {
+		unit := allUnits[i]
+		cells := unit.Cells()
+
+		// Only cells with 2..n candidates can be part of the subset
+		var indexes []int
+		for idx, c := range cells {
+			count := bits.OnesCount16(b.cand[c.Row][c.Col])
+			if count >= 2 && count <= n {
+				indexes = append(indexes, idx)
+			}
+		}
+
+		var found *Step
+		combinations(indexes, n, func(subset []int) bool {
+			var mask uint16
+			var members []Cell
+			for _, idx := range subset {
+				c := cells[idx]
+				mask |= b.cand[c.Row][c.Col]
+				members = append(members, c)
+			}
+			if bits.OnesCount16(mask) != n {
+				return false
+			}
+			var elims []Candidate
+			for _, num := range maskDigits(mask) {
+				elims = append(elims, b.eliminate(unit, num, members)...)
+			}
+			if len(elims) == 0 {
+				return false
+			}
+			found = &Step{
+				Technique:    technique,
+				Eliminations: elims,
+				Cells:        members,
+				Digits:       maskDigits(mask),
+				Unit:         &unit,
+			}
+			return true
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findHiddenSubset looks for n digits that only fit in the same n cells of a
+// unit, which removes every other candidate from those cells
+func findHiddenSubset(b *board, n int) *Step {
+	technique := HiddenPair
+	if n == 3 {
+		technique = HiddenTriple
+	}
+
+	for i := range allUnits {
+		unit := allUnits[i]
+
+		// Only digits appearing in 2..n cells can be part of the subset
+		var digits []int
+		var places [10][]Cell
+		for num := 1; num <= 9; num++ {
+			places[num] = b.cellsWith(unit, num)
+			if len(places[num]) >= 2 && len(places[num]) <= n {
+				digits = append(digits, num)
+			}
+		}
+
+		var found *Step
+		combinations(digits, n, func(subset []int) bool {
+			var members []Cell
+			var keep uint16
+			for _, num := range subset {
+				keep |= 1 << num
+				for _, c := range places[num] {
+					if !containsCell(members, c) {
+						members = append(members, c)
+					}
+				}
+			}
+			if len(members) != n {
+				return false
+			}
+			var elims []Candidate
+			for _, c := range members {
+				for _, num := range maskDigits(b.cand[c.Row][c.Col] &^ keep) {
+					elims = append(elims, Candidate{c.Row, c.Col, num})
+				}
+			}
+			if len(elims) == 0 {
+				return false
+			}
+			found = &Step{
+				Technique:    technique,
+				Eliminations: elims,
+				Cells:        members,
+				Digits:       append([]int(nil), subset...),
+				Unit:         &unit,
+			}
+			return true
+		})
+		if found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// findFish looks for an X-Wing (n=2) or Swordfish (n=3): n rows where a digit
+// is limited to the same n columns, or the other way round. The digit can then
+// be removed from those columns (rows) everywhere else.
+func findFish(b *board, n int) *Step {
+	technique := XWing
+	if n == 3 {
+		technique = Swordfish
+	}
+
+	for num := 1; num <= 9; num++ {
+		for _, byRow := range []bool{true, false} {
+			// positions[line] is the mask of cross lines holding the digit
+			var positions [9]uint16
+			var lines []int
+			for line := 0; line < 9; line++ {
+				for cross := 0; cross < 9; cross++ {
+					row, col := line, cross
+					if !byRow {
+						row, col = cross, line
+					}
+					if b.cand.Has(row, col, num) {
+						positions[line] |= 1 << cross
+					}
+				}
+				if count := bits.OnesCount16(positions[line]); count >= 2 && count <= n {
+					lines = append(lines, line)
+				}
+			}
+
+			var found *Step
+			combinations(lines, n, func(subset []int) bool {
+				var crossMask uint16
+				for _, line := range subset {
+					crossMask |= positions[line]
+				}
+				if bits.OnesCount16(crossMask) != n {
+					return false
+				}
+
+				var members []Cell
+				var elims []Candidate
+				for cross := 0; cross < 9; cross++ {
+					if crossMask&(1<<cross) == 0 {
+						continue
+					}
+					for line := 0; line < 9; line++ {
+						row, col := line, cross
+						if !byRow {
+							row, col = cross, line
+						}
+						if !b.cand.Has(row, col, num) {
+							continue
+						}
+						if containsInt(subset, line) {
+							members = append(members, Cell{row, col})
+						} else {
+							elims = append(elims, Candidate{row, col, num})
+						}
+					}
+				}
+				if len(elims) == 0 {
+					return false
+				}
+				found = &Step{
+					Technique:    technique,
+					Eliminations: elims,
+					Cells:        members,
+					Digits:       []int{num},
+				}
+				return true
+			})
+			if found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func containsInt(items []int, v int) bool {
+	for _, item := range items {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+// bivalueCells lists the empty cells with exactly two candidates
+func (b *board) bivalueCells() []Cell {
+	var cells []Cell
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if bits.OnesCount16(b.cand[row][col]) == 2 {
+				cells = append(cells, Cell{row, col})
+			}
+		}
+	}
+	return cells
+}
+
+// eliminateSeenBy collects the candidates of num in cells that see both a and b
+func (b *board) eliminateSeenBy(num int, first, second Cell, exclude []Cell) []Candidate {
+	var elims []Candidate
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			c := Cell{row, col}
+			if containsCell(exclude, c) || !b.cand.Has(row, col, num) {
+				continue
+			}
+			if sees(c, first) && sees(c, second) {
+				elims = append(elims, Candidate{row, col, num})
+			}
+		}
+	}
+	return elims
+}
+
+// findXYWing looks for a bivalue pivot {x,y} seeing two bivalue pincers {x,z}
+// and {y,z}. Whichever value the pivot takes one pincer must be z, so z is
+// removed from every cell seeing both pincers.
+func findXYWing(b *board) *Step {
+	cells := b.bivalueCells()
+	for _, pivot := range cells {
+		pivotMask := b.cand[pivot.Row][pivot.Col]
+		for i, first := range cells {
+			if !sees(pivot, first) {
+				continue
+			}
+			firstMask := b.cand[first.Row][first.Col]
+			shared := firstMask & pivotMask
+			if bits.OnesCount16(shared) != 1 {
+				continue
+			}
+			z := firstMask &^ shared
+			for _, second := range cells[i+1:] {
+				if !sees(pivot, second) {
+					continue
+				}
+				// The second pincer holds z and the pivot digit the first one lacks
+				if b.cand[second.Row][second.Col] != z|(pivotMask&^shared) {
+					continue
+				}
+				num := bits.TrailingZeros16(z)
+				members := []Cell{pivot, first, second}
+				if elims := b.eliminateSeenBy(num, first, second, members); len(elims) > 0 {
+					return &Step{
+						Technique:    XYWing,
+						Eliminations: elims,
+						Cells:        members,
+						Digits:       maskDigits(pivotMask | z),
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// maxChainLength bounds the XY-Chain search
+const maxChainLength = 8
+
+// findXYChain looks for a chain of bivalue cells, each seeing the next, where
+// linking through the shared digits leads from a cell that is "a or x" to a
+// cell that is "y or a". One of the two ends must be a, so a is removed from
+// every cell seeing both ends.
+func findXYChain(b *board) *Step {
+	cells := b.bivalueCells()
+	for _, start := range cells {
+		for _, a := range b.cand.Digits(start.Row, start.Col) {
+			// If start isn't a, it is the other digit, which links onwards
+			link := bits.TrailingZeros16(b.cand[start.Row][start.Col] &^ (1 << a))
+			chain := []Cell{start}
+			if s := b.extendChain(cells, chain, a, link); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// extendChain continues an XY-Chain whose last cell is forced to link when
+// the chain's start isn't a
+func (b *board) extendChain(cells, chain []Cell, a, link int) *Step {
+	last := chain[len(chain)-1]
+	for _, next := range cells {
+		if containsCell(chain, next) || !sees(last, next) || !b.cand.Has(next.Row, next.Col, link) {
+			continue
+		}
+		// next can't be link, so it is its other digit
+		other := bits.TrailingZeros16(b.cand[next.Row][next.Col] &^ (1 << link))
+		extended := append(append([]Cell(nil), chain...), next)
+
+		// Three cells is an XY-Wing, so chains start at four
+		if other == a && len(extended) >= 4 {
+			if elims := b.eliminateSeenBy(a, extended[0], next, extended); len(elims) > 0 {
+				return &Step{
+					Technique:    XYChain,
+					Eliminations: elims,
+					Cells:        extended,
+					Digits:       []int{a},
+				}
+			}
+		}
+		if len(extended) < maxChainLength {
+			if s := b.extendChain(cells, extended, a, other); s != nil {
+				return s
+			}
+		}
+	}
+	return nil
+}
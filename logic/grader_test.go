@@ -0,0 +1,204 @@
+package logic
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// generatePuzzle builds a minimal-ish unique puzzle for a seed
+func generatePuzzle(seed int64) (Puzzle, Puzzle) {
+	solution := GenerateSolvedGrid(rand.New(rand.NewSource(seed)))
+	grid := [9][9]int(solution)
+	RemoveNumbersFromGrid(nil, &grid, 5)
+	return grid, solution
+}
+
+// Test that every deduction the techniques make agrees with the real solution
+func TestTechniquesAgreeWithSolution(t *testing.T) {
+	used := make(map[Technique]int)
+	for seed := int64(1); seed <= 60; seed++ {
+		puzzle, solution := generatePuzzle(seed)
+		b := &board{grid: puzzle, cand: NewCandidates(puzzle)}
+		for !b.solved() {
+			s := nextLogicalStep(b)
+			if s == nil {
+				s = guessStep(b, solution)
+			}
+			if s.Value != 0 && solution[s.Cell.Row][s.Cell.Col] != s.Value {
+				t.Fatalf("seed %d: %v placed %d at %v; solution has %d",
+					seed, s.Technique, s.Value, s.Cell, solution[s.Cell.Row][s.Cell.Col])
+			}
+			for _, e := range s.Eliminations {
+				if solution[e.Row][e.Col] == e.Value {
+					t.Fatalf("seed %d: %v removed the solution digit %d from R%dC%d",
+						seed, s.Technique, e.Value, e.Row+1, e.Col+1)
+				}
+			}
+			if s.Value == 0 && len(s.Eliminations) == 0 {
+				t.Fatalf("seed %d: %v made no progress", seed, s.Technique)
+			}
+			used[s.Technique]++
+			b.apply(s)
+		}
+		if b.grid != solution {
+			t.Fatalf("seed %d: logical solve ended on %v; want %v", seed, b.grid, solution)
+		}
+	}
+	t.Logf("techniques used: %v", used)
+}
+
+// Test grading an easy puzzle and a finished grid
+func TestGrade(t *testing.T) {
+	easy := Grade(parseGrid(t, testPuzzle))
+	if easy.Hardest > NakedSingle {
+		t.Errorf("Grade(easy).Hardest = %v; want a single", easy.Hardest)
+	}
+	if easy.Steps != 51 {
+		t.Errorf("Grade(easy).Steps = %d; want 51 (one per empty cell)", easy.Steps)
+	}
+	if easy.Score < easy.Steps {
+		t.Errorf("Grade(easy).Score = %d; want at least %d", easy.Score, easy.Steps)
+	}
+
+	done := Grade(parseGrid(t, testAnswer))
+	if done != (Rating{}) {
+		t.Errorf("Grade(solved) = %+v; want zero rating", done)
+	}
+
+	conflict := parseGrid(t, testPuzzle)
+	conflict[0][2] = 5
+	if r := Grade(conflict); r.Hardest != Backtracking {
+		t.Errorf("Grade(conflict).Hardest = %v; want Backtracking", r.Hardest)
+	}
+}
+
+// emptyBoard has every digit as a candidate everywhere
+func emptyBoard() *board {
+	b := &board{}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			b.cand[row][col] = allDigits
+		}
+	}
+	return b
+}
+
+// Test X-Wing detection on a hand built candidate layout
+func TestFindXWing(t *testing.T) {
+	b := emptyBoard()
+	// 5 can only go in columns 2 and 8 in rows 1 and 5
+	for _, row := range []int{0, 4} {
+		for col := 0; col < 9; col++ {
+			if col != 1 && col != 7 {
+				b.cand[row][col] &^= 1 << 5
+			}
+		}
+	}
+
+	s := findFish(b, 2)
+	if s == nil {
+		t.Fatal("findFish(2) found nothing")
+	}
+	if s.Technique != XWing || len(s.Cells) != 4 {
+		t.Fatalf("findFish(2) = %v with %d cells; want X-Wing with 4", s.Technique, len(s.Cells))
+	}
+	if len(s.Eliminations) != 14 { // 7 other rows in each of the two columns
+		t.Errorf("X-Wing removed %d candidates; want 14", len(s.Eliminations))
+	}
+	for _, e := range s.Eliminations {
+		if e.Value != 5 || (e.Col != 1 && e.Col != 7) || e.Row == 0 || e.Row == 4 {
+			t.Errorf("unexpected X-Wing elimination %+v", e)
+		}
+	}
+}
+
+// Test XY-Wing detection on a hand built candidate layout
+func TestFindXYWing(t *testing.T) {
+	b := emptyBoard()
+	b.cand[0][0] = 1<<1 | 1<<2 // Pivot {1,2}
+	b.cand[0][5] = 1<<1 | 1<<3 // Pincer {1,3} in the same row
+	b.cand[2][1] = 1<<2 | 1<<3 // Pincer {2,3} in the same box
+
+	s := findXYWing(b)
+	if s == nil {
+		t.Fatal("findXYWing found nothing")
+	}
+	// Cells seeing both pincers: R1C2, R1C3 (row 1 and box 1) and R3C4-R3C6 (row 3 and box 2)
+	want := map[Candidate]bool{
+		{0, 1, 3}: true, {0, 2, 3}: true,
+		{2, 3, 3}: true, {2, 4, 3}: true, {2, 5, 3}: true,
+	}
+	if len(s.Eliminations) != len(want) {
+		t.Fatalf("XY-Wing removed %v; want %d candidates", s.Eliminations, len(want))
+	}
+	for _, e := range s.Eliminations {
+		if !want[e] {
+			t.Errorf("unexpected XY-Wing elimination %+v", e)
+		}
+	}
+}
+
+// Test the hint engine on an easy puzzle
+func TestNextStep(t *testing.T) {
+	puzzle := parseGrid(t, testPuzzle)
+	solution := parseGrid(t, testAnswer)
+
+	s, err := NextStep(puzzle, NewCandidates(puzzle))
+	if err != nil {
+		t.Fatalf("NextStep returned error: %v", err)
+	}
+	if s.Technique != HiddenSingle {
+		t.Errorf("NextStep technique = %v; want Hidden single", s.Technique)
+	}
+	if s.Value == 0 || solution[s.Cell.Row][s.Cell.Col] != s.Value {
+		t.Errorf("NextStep placed %d at %v; want the solution digit", s.Value, s.Cell)
+	}
+	if len(s.Cells) == 0 || s.Unit == nil {
+		t.Error("NextStep should report the cells and unit justifying the step")
+	}
+
+	// Candidates missing the right answer are rejected
+	var none Candidates
+	if _, err := NextStep(puzzle, none); err != ErrWrongCandidates {
+		t.Errorf("NextStep(no candidates) error = %v; want ErrWrongCandidates", err)
+	}
+
+	// A wrong digit on the board means there is no solution to hint towards
+	wrong := puzzle
+	wrong[0][2] = 1 // The answer is 4
+	if _, err := NextStep(wrong, NewCandidates(wrong)); err != ErrNoSolution {
+		t.Errorf("NextStep(wrong board) error = %v; want ErrNoSolution", err)
+	}
+
+	if _, err := NextStep(solution, NewCandidates(solution)); err != ErrNoStep {
+		t.Errorf("NextStep(solved) error = %v; want ErrNoStep", err)
+	}
+}
+
+// Test step explanations
+func TestStepExplain(t *testing.T) {
+	box := Unit{BoxUnit, 1}
+	s := &Step{
+		Technique: HiddenSingle,
+		Cell:      Cell{2, 4},
+		Value:     7,
+		Unit:      &box,
+	}
+	want := "Hidden single: 7 must go in R3C5 (only place in box 2)"
+	if got := s.Explain(); got != want {
+		t.Errorf("Explain() = %q; want %q", got, want)
+	}
+
+	row := Unit{RowUnit, 0}
+	pair := &Step{
+		Technique:    NakedPair,
+		Cells:        []Cell{{0, 0}, {0, 4}},
+		Digits:       []int{3, 7},
+		Eliminations: []Candidate{{0, 1, 3}, {0, 1, 7}, {0, 8, 3}},
+		Unit:         &row,
+	}
+	want = "Naked pair: R1C1, R1C5 can only hold 3 and 7, so remove them from R1C2, R1C9"
+	if got := pair.Explain(); got != want {
+		t.Errorf("Explain() = %q; want %q", got, want)
+	}
+}
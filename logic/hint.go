@@ -0,0 +1,131 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrNoStep is returned when none of the techniques can make progress
+	ErrNoStep = errors.New("no logical step found")
+	// ErrWrongCandidates is returned when the candidates rule out the real answer of a cell
+	ErrWrongCandidates = errors.New("candidates exclude the solution")
+)
+
+// String returns the cell in one-based RxCy notation
+func (c Cell) String() string {
+	return fmt.Sprintf("R%dC%d", c.Row+1, c.Col+1)
+}
+
+// NextStep finds the easiest deduction available for the puzzle. The
+// candidates are the ones the player is working with; anything that clashes
+// with a placed digit is dropped first. It fails if the board already
+// contradicts the solution, if the candidates rule out a correct digit, or if
+// no technique on the ladder applies.
+func NextStep(p Puzzle, c Candidates) (*Step, error) {
+	solution, err := Solve(p)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &board{grid: p, cand: NewCandidates(p)}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if p[row][col] != 0 {
+				continue
+			}
+			b.cand[row][col] &= c[row][col]
+			if !b.cand.Has(row, col, solution[row][col]) {
+				return nil, ErrWrongCandidates
+			}
+		}
+	}
+
+	if s := nextLogicalStep(b); s != nil {
+		return s, nil
+	}
+	return nil, ErrNoStep
+}
+
+// Explain describes the step in a sentence, for example
+// "Hidden single: 7 must go in R3C5 (only place in box 2)"
+func (s *Step) Explain() string {
+	digit := 0
+	if len(s.Digits) > 0 {
+		digit = s.Digits[0]
+	}
+
+	switch s.Technique {
+	case HiddenSingle:
+		return fmt.Sprintf("%v: %d must go in %v (only place in %v)", s.Technique, s.Value, s.Cell, s.Unit)
+	case NakedSingle:
+		return fmt.Sprintf("%v: %d is the only candidate left in %v", s.Technique, s.Value, s.Cell)
+	case Backtracking:
+		return fmt.Sprintf("%v: try %d in %v", s.Technique, s.Value, s.Cell)
+	case PointingPair:
+		line := "its line"
+		if lines := sharedLines(s.Cells); len(lines) > 0 {
+			line = lines[0].String()
+		}
+		return fmt.Sprintf("%v: in %v, %d only fits in %v, so remove it from %s",
+			s.Technique, s.Unit, digit, line, eliminatedCells(s.Eliminations))
+	case BoxLineReduction:
+		box := Unit{BoxUnit, boxIndex(s.Cells[0].Row, s.Cells[0].Col)}
+		return fmt.Sprintf("%v: in %v, %d only fits in %v, so remove it from %s",
+			s.Technique, s.Unit, digit, box, eliminatedCells(s.Eliminations))
+	case NakedPair, NakedTriple:
+		return fmt.Sprintf("%v: %s can only hold %s, so remove them from %s",
+			s.Technique, joinCells(s.Cells), joinDigits(s.Digits), eliminatedCells(s.Eliminations))
+	case HiddenPair, HiddenTriple:
+		return fmt.Sprintf("%v: %s only fit in %s in %v, so remove the other candidates there",
+			s.Technique, joinDigits(s.Digits), joinCells(s.Cells), s.Unit)
+	case XWing, Swordfish:
+		return fmt.Sprintf("%v: %d is locked into %s, so remove it from %s",
+			s.Technique, digit, joinCells(s.Cells), eliminatedCells(s.Eliminations))
+	case XYWing:
+		return fmt.Sprintf("%v: pivot %v with pincers %v and %v, so remove %d from %s",
+			s.Technique, s.Cells[0], s.Cells[1], s.Cells[2], s.Eliminations[0].Value, eliminatedCells(s.Eliminations))
+	case XYChain:
+		return fmt.Sprintf("%v: %v or %v must be %d, so remove it from %s",
+			s.Technique, s.Cells[0], s.Cells[len(s.Cells)-1], digit, eliminatedCells(s.Eliminations))
+	default:
+		return s.Technique.String()
+	}
+}
+
+// joinCells lists cells as "R1C1, R1C5"
+func joinCells(cells []Cell) string {
+	names := make([]string, len(cells))
+	for i, c := range cells {
+		names[i] = c.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// joinDigits lists digits as "3, 5 and 7"
+func joinDigits(digits []int) string {
+	names := make([]string, len(digits))
+	for i, num := range digits {
+		names[i] = fmt.Sprint(num)
+	}
+	if len(names) < 2 {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " and " + names[len(names)-1]
+}
+
+// eliminatedCells lists the cells losing a candidate, summarising long lists
+func eliminatedCells(elims []Candidate) string {
+	var cells []Cell
+	for _, e := range elims {
+		c := Cell{e.Row, e.Col}
+		if !containsCell(cells, c) {
+			cells = append(cells, c)
+		}
+	}
+	if len(cells) > 3 {
+		return fmt.Sprintf("%d cells", len(cells))
+	}
+	return joinCells(cells)
+}
@@ -0,0 +1,167 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// Pack is a collection of puzzles with its descriptive details
+type Pack struct {
+	Title   string
+	Author  string
+	Puzzles []PackPuzzle
+}
+
+// PackPuzzle is one puzzle of a pack
+type PackPuzzle struct {
+	ID         string // Unique within the pack
+	Puzzle     Puzzle
+	Difficulty string  // Rating given by the pack's author, empty if unrated
+	Solution   *Puzzle // Known answer, nil if the pack doesn't give one
+}
+
+// NewPack wraps anonymous puzzles in a pack, numbering them from 1
+func NewPack(puzzles []Puzzle) *Pack {
+	pack := &Pack{Puzzles: make([]PackPuzzle, len(puzzles))}
+	for i, p := range puzzles {
+		pack.Puzzles[i] = PackPuzzle{ID: strconv.Itoa(i + 1), Puzzle: p}
+	}
+	return pack
+}
+
+// Grids returns the pack's puzzles without their details
+func (p *Pack) Grids() []Puzzle {
+	grids := make([]Puzzle, len(p.Puzzles))
+	for i, pp := range p.Puzzles {
+		grids[i] = pp.Puzzle
+	}
+	return grids
+}
+
+// LoadPack reads a pack file in any of the supported formats
+func LoadPack(filename string) (*Pack, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer file.Close()
+
+	return ReadPack(file)
+}
+
+// ReadPack reads a pack in any of the supported formats, detecting which one
+// from the content. Formats without metadata give an untitled pack.
+func ReadPack(r io.Reader) (*Pack, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	return decodePack(data, DetectFormat(data))
+}
+
+// ReadPackFormat reads a pack written in the given format
+func ReadPackFormat(r io.Reader, f Format) (*Pack, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading file: %v", err)
+	}
+	return decodePack(data, f)
+}
+
+// jsonPack is the JSON layout of a pack, with puzzles as 81 character strings
+type jsonPack struct {
+	Title   string       `json:"title,omitempty"`
+	Author  string       `json:"author,omitempty"`
+	Puzzles []jsonPuzzle `json:"puzzles"`
+}
+
+type jsonPuzzle struct {
+	ID         string `json:"id,omitempty"`
+	Puzzle     string `json:"puzzle"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Solution   string `json:"solution,omitempty"`
+}
+
+// readJSONPack decodes a JSON pack, checking that ids are unique and that any
+// solution given really solves its puzzle
+func readJSONPack(data []byte) (*Pack, error) {
+	var file jsonPack
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode pack: %v", err)
+	}
+
+	pack := &Pack{Title: file.Title, Author: file.Author}
+	seen := make(map[string]bool)
+	for i, jp := range file.Puzzles {
+		id := jp.ID
+		if id == "" {
+			id = strconv.Itoa(i + 1)
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate puzzle id %q", id)
+		}
+		seen[id] = true
+
+		p, err := ParsePuzzle(jp.Puzzle)
+		if err != nil {
+			return nil, fmt.Errorf("puzzle %s: %v", id, err)
+		}
+		pp := PackPuzzle{ID: id, Puzzle: p, Difficulty: jp.Difficulty}
+		if jp.Solution != "" {
+			solution, err := ParsePuzzle(jp.Solution)
+			if err != nil {
+				return nil, fmt.Errorf("puzzle %s solution: %v", id, err)
+			}
+			if !solves(solution, p) {
+				return nil, fmt.Errorf("puzzle %s: solution does not solve the puzzle", id)
+			}
+			pp.Solution = &solution
+		}
+		pack.Puzzles = append(pack.Puzzles, pp)
+	}
+	return pack, nil
+}
+
+func writeJSONPack(w io.Writer, pack *Pack) error {
+	file := jsonPack{
+		Title:   pack.Title,
+		Author:  pack.Author,
+		Puzzles: make([]jsonPuzzle, len(pack.Puzzles)),
+	}
+	for i, pp := range pack.Puzzles {
+		file.Puzzles[i] = jsonPuzzle{
+			ID:         pp.ID,
+			Puzzle:     pp.Puzzle.String(),
+			Difficulty: pp.Difficulty,
+		}
+		if pp.Solution != nil {
+			file.Puzzles[i].Solution = pp.Solution.String()
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(file); err != nil {
+		return fmt.Errorf("failed to encode pack: %v", err)
+	}
+	return nil
+}
+
+// solves reports whether solution is a complete valid grid agreeing with the
+// puzzle's clues
+func solves(solution, p Puzzle) bool {
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if solution[row][col] == 0 {
+				return false
+			}
+			if p[row][col] != 0 && p[row][col] != solution[row][col] {
+				return false
+			}
+		}
+	}
+	_, err := Solve(solution) // Rejects grids breaking the rules
+	return err == nil
+}
@@ -1,18 +1,35 @@
 package logic
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"math/rand"
 	"os"
-	"strings"
 )
 
+// MoveKind says what a move in the game changed
+type MoveKind int
+
+const (
+	NumberMove     MoveKind = iota // A number was placed or erased
+	MarkMove                       // A pencil mark was toggled
+	ClearMarksMove                 // Pencil marks of several cells were changed at once
+)
+
+// MarkChange records the pencil marks of a cell before and after a move
+type MarkChange struct {
+	Row, Col int
+	Old, New uint16
+	Centre   bool // The change is to the cell's centre marks
+}
+
 // Action represents a move in the game
 type Action struct {
-	Row, Col int
+	Kind     MoveKind
+	Row, Col int // Cell the move was made on, -1 for ClearMarksMove
 	OldValue int
 	NewValue int
+	Marks    []MarkChange // Pencil marks changed by the move
 }
 
 //
@@ -23,7 +40,42 @@ type Puzzle [9][9]int
 // GameLogic represents the game logic
 type GameLogic struct {
 	Puzzle    Puzzle
+	Givens    [9][9]bool   // Clues printed in the puzzle, which can't be changed
+	Marks     [9][9]uint16 // Pencil marks for each cell, bit n set when n is pencilled in
+	Centre    [9][9]uint16 // Centre marks, a second set of pencil marks kept apart from Marks
 	MoveStack []Action
+	RedoStack []Action // Undone moves, most recent last. Cleared by any new move
+}
+
+var (
+	// ErrGivenCell is returned when trying to change one of the puzzle's clues
+	ErrGivenCell = errors.New("cell holds a given clue")
+	// ErrNothingToUndo is returned by UndoMove when the move stack is empty
+	ErrNothingToUndo = errors.New("no moves to undo")
+	// ErrNothingToRedo is returned by RedoMove when there is no undone move
+	ErrNothingToRedo = errors.New("no moves to redo")
+	// ErrUndoConflict is returned by UndoCell when the number it would bring
+	// back clashes with one placed since in the same row, column or box
+	ErrUndoConflict = errors.New("restored number would clash with another cell")
+)
+
+// NewGameLogic starts a game on the puzzle, marking every filled cell as a given
+func NewGameLogic(p Puzzle) *GameLogic {
+	g := &GameLogic{
+		Puzzle:    p,
+		MoveStack: []Action{},
+	}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			g.Givens[row][col] = p[row][col] != 0
+		}
+	}
+	return g
+}
+
+// IsGiven reports whether the cell holds one of the puzzle's clues
+func (g *GameLogic) IsGiven(row, col int) bool {
+	return g.Givens[row][col]
 }
 
 // GameStatus represents the current state of the game
@@ -43,136 +95,315 @@ func LoadPuzzles(filename string) ([]Puzzle, error) {
 	}
 	defer file.Close()
 
-	var puzzles []Puzzle
-	var currentPuzzle [9][9]int
-	scanner := bufio.NewScanner(file)
-	row := 0
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" { // Blank line indicates end of a puzzle
-			if row == 9 { // Only add if we've completed reading 9 rows
-				puzzles = append(puzzles, currentPuzzle)
-				row = 0
-			}
-			continue
-		}
+	return ReadPuzzles(file)
+}
 
-		if len(line) != 9 {
-			return nil, fmt.Errorf("invalid row length in puzzle: %v. The row has %v", line, len(line))
-		}
+// Function to select a random puzzle from the loaded puzzles.
+// A nil rng falls back to the global math/rand source.
+func GetRandomPuzzle(rng *rand.Rand, puzzles []Puzzle) [9][9]int {
+	return puzzles[randSource(rng).Intn(len(puzzles))]
+}
 
-		for col, char := range line {
-			if char < '0' || char > '9' {
-				return nil, fmt.Errorf("invalid character in puzzle: %v", char)
-			}
-			currentPuzzle[row][col] = int(char - '0')
-		}
+// Remove numbers to make the puzzle playable, picking cells at random. A clue
+// is only removed if the puzzle still has exactly one solution afterwards, so
+// on harder levels fewer cells than requested may be blanked. A nil rng falls
+// back to the global math/rand source.
+func RemoveNumbersFromGrid(rng *rand.Rand, grid *[9][9]int, difficulty int) {
+	RemoveClues(rng, grid, RandomRemoval, difficulty)
+}
 
-		row++
+// Add moves to the stack. A new move starts a fresh line of play, so
+// anything that was undone can no longer be redone.
+func (g *GameLogic) AddMove(row, col, oldValue, newValue int) {
+	oldmove := g.Puzzle[row][col]
+	action := Action{
+		Row:      row,
+		Col:      col,
+		OldValue: oldmove,
+		NewValue: newValue,
+	}
+	g.push(action)
+}
+
+// push records a move and applies it
+func (g *GameLogic) push(action Action) {
+	g.MoveStack = append(g.MoveStack, action)
+	g.RedoStack = nil
+	g.apply(action)
+}
+
+// apply sets the cell value and pencil marks a move ends with
+func (g *GameLogic) apply(action Action) {
+	if action.Kind == NumberMove {
+		g.Puzzle[action.Row][action.Col] = action.NewValue
+	}
+	for _, m := range action.Marks {
+		*g.markMask(m) = m.New
 	}
+}
 
-	// Add the last puzzle if no trailing blank line
-	if row == 9 {
-		puzzles = append(puzzles, currentPuzzle)
+// revert restores the cell value and pencil marks a move started from
+func (g *GameLogic) revert(action Action) {
+	if action.Kind == NumberMove {
+		g.Puzzle[action.Row][action.Col] = action.OldValue
+	}
+	for i := len(action.Marks) - 1; i >= 0; i-- {
+		m := action.Marks[i]
+		*g.markMask(m) = m.Old
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading file: %v", err)
+// markMask returns the pencil marks a change is made to
+func (g *GameLogic) markMask(m MarkChange) *uint16 {
+	if m.Centre {
+		return &g.Centre[m.Row][m.Col]
 	}
+	return &g.Marks[m.Row][m.Col]
+}
 
-	return puzzles, nil
+// SetCell writes a player's number into the cell, or erases it when num is 0,
+// recording the change so it can be undone. Placing a number also clears the
+// cell's pencil and centre marks as part of the same move. Given clues are
+// refused.
+func (g *GameLogic) SetCell(row, col, num int) error {
+	return g.setCell(row, col, num, false)
 }
 
-// Function to select a random puzzle from the loaded puzzles
-func GetRandomPuzzle(puzzles []Puzzle) [9][9]int {
-	//rand.Seed(time.Now().UnixNano())
-	return puzzles[rand.Intn(len(puzzles))]
+// SetCellAndEliminate is SetCell that also takes the number out of the pencil
+// and centre marks of every cell sharing the row, column or box, all as one
+// move
+func (g *GameLogic) SetCellAndEliminate(row, col, num int) error {
+	return g.setCell(row, col, num, true)
 }
 
-// Function to shuffle the puzzle
-func ShuffleAsh(grid *[9][9]int) {
-	numbers := rand.Perm(9)
-	for row := range grid {
-		for col := range grid[row] {
-			if grid[row][col] != 0 {
-				grid[row][col] = numbers[grid[row][col]-1] + 1
+func (g *GameLogic) setCell(row, col, num int, eliminate bool) error {
+	if g.IsGiven(row, col) {
+		return ErrGivenCell
+	}
+	if num < 0 || num > 9 {
+		return fmt.Errorf("invalid number: %d", num)
+	}
+	if g.Puzzle[row][col] == num {
+		return nil // Nothing changes, keep the move stack clean
+	}
+
+	action := Action{
+		Row:      row,
+		Col:      col,
+		OldValue: g.Puzzle[row][col],
+		NewValue: num,
+	}
+	if num != 0 && g.Marks[row][col] != 0 {
+		action.Marks = append(action.Marks, MarkChange{row, col, g.Marks[row][col], 0, false})
+	}
+	if num != 0 && g.Centre[row][col] != 0 {
+		action.Marks = append(action.Marks, MarkChange{row, col, g.Centre[row][col], 0, true})
+	}
+	if num != 0 && eliminate {
+		placed := Cell{row, col}
+		for r := 0; r < 9; r++ {
+			for c := 0; c < 9; c++ {
+				if !sees(placed, Cell{r, c}) {
+					continue
+				}
+				if old := g.Marks[r][c]; old&(1<<num) != 0 {
+					action.Marks = append(action.Marks, MarkChange{r, c, old, old &^ (1 << num), false})
+				}
+				if old := g.Centre[r][c]; old&(1<<num) != 0 {
+					action.Marks = append(action.Marks, MarkChange{r, c, old, old &^ (1 << num), true})
+				}
 			}
 		}
 	}
+	g.push(action)
+	return nil
+}
 
-	//Shuffle the rows withing each block.
-	for i := 0; i < 9; i += 3 {
-		shuffleRowsInBlock(grid, i)
-	}
+// HasPencilMark reports whether num is pencilled into the cell
+func (g *GameLogic) HasPencilMark(row, col, num int) bool {
+	return g.Marks[row][col]&(1<<num) != 0
+}
 
-	//Shuffle the columns withing each block.
-	for i := 0; i < 9; i += 3 {
-		shuffleColsInBlock(grid, i)
-	}
+// HasCentreMark reports whether num is in the cell's centre marks
+func (g *GameLogic) HasCentreMark(row, col, num int) bool {
+	return g.Centre[row][col]&(1<<num) != 0
+}
+
+// TogglePencilMark adds or removes a pencil mark as an undoable move
+func (g *GameLogic) TogglePencilMark(row, col, num int) {
+	g.toggleMark(row, col, num, false)
+}
 
+// ToggleCentreMark adds or removes a centre mark as an undoable move
+func (g *GameLogic) ToggleCentreMark(row, col, num int) {
+	g.toggleMark(row, col, num, true)
 }
 
-// Function to shuffle the rows within a block
-func shuffleRowsInBlock(grid *[9][9]int, blockStart int) {
-	rows := rand.Perm(3)
-	for i := 0; i < 3; i++ {
-		temp := grid[blockStart+i]
-		grid[blockStart+i] = grid[blockStart+rows[i]]
-		grid[blockStart+rows[i]] = temp
+func (g *GameLogic) toggleMark(row, col, num int, centre bool) {
+	if num < 1 || num > 9 {
+		return
 	}
+	change := MarkChange{Row: row, Col: col, Centre: centre}
+	change.Old = *g.markMask(change)
+	change.New = change.Old ^ (1 << num)
+	g.push(Action{
+		Kind:     MarkMove,
+		Row:      row,
+		Col:      col,
+		OldValue: g.Puzzle[row][col],
+		NewValue: g.Puzzle[row][col],
+		Marks:    []MarkChange{change},
+	})
 }
 
-// Function to shuffle the columns within a block
-func shuffleColsInBlock(grid *[9][9]int, blockStart int) {
-	for i := 0; i < 3; i++ {
-		cols := rand.Perm(3)
-		for j := 0; j < 9; j++ {
-			temp := grid[j][blockStart+i]
-			grid[j][blockStart+i] = grid[j][blockStart+cols[i]]
-			grid[j][blockStart+cols[i]] = temp
+// ClearPencilMarks removes every pencil and centre mark on the board as a
+// single undoable move. It returns false, recording nothing, if there were
+// none.
+func (g *GameLogic) ClearPencilMarks() bool {
+	var changes []MarkChange
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if g.Marks[row][col] != 0 {
+				changes = append(changes, MarkChange{row, col, g.Marks[row][col], 0, false})
+			}
+			if g.Centre[row][col] != 0 {
+				changes = append(changes, MarkChange{row, col, g.Centre[row][col], 0, true})
+			}
 		}
 	}
+	return g.pushMarks(changes)
 }
 
-// Remove numbers to make the puzzle playable
-func RemoveNumbersFromGrid(grid *[9][9]int, difficulty int) {
-	blanks := 20 + difficulty*10 // Control how many numbers to remove based on difficulty
-	for blanks > 0 {
-		row := rand.Intn(9)
-		col := rand.Intn(9)
-		if grid[row][col] != 0 {
-			grid[row][col] = 0
-			blanks--
+// FillCandidates pencils in every digit each empty cell can still take given
+// the numbers placed, replacing the marks there, as a single undoable move.
+// It returns false, recording nothing, if the marks were already like that.
+func (g *GameLogic) FillCandidates() bool {
+	candidates := NewCandidates(g.Puzzle)
+	var changes []MarkChange
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if g.Puzzle[row][col] == 0 && g.Marks[row][col] != candidates[row][col] {
+				changes = append(changes, MarkChange{row, col, g.Marks[row][col], candidates[row][col], false})
+			}
 		}
 	}
+	return g.pushMarks(changes)
 }
 
-// Add moves to the stack
-func (g *GameLogic) AddMove(row, col, oldValue, newValue int) {
-	oldmove := g.Puzzle[row][col]
-	action := Action{
-		Row:      row,
-		Col:      col,
-		OldValue: oldmove,
-		NewValue: newValue,
+// pushMarks records a bulk pencil mark change, skipping empty ones
+func (g *GameLogic) pushMarks(changes []MarkChange) bool {
+	if len(changes) == 0 {
+		return false
 	}
-	g.MoveStack = append(g.MoveStack, action)
-	g.Puzzle[row][col] = newValue
+	g.push(Action{
+		Kind:  ClearMarksMove,
+		Row:   -1,
+		Col:   -1,
+		Marks: changes,
+	})
+	return true
 }
 
-// Undo the last move
-func (g *GameLogic) UndoMove() {
+// Undo the last move, returning the action that was reverted
+func (g *GameLogic) UndoMove() (Action, error) {
 	if len(g.MoveStack) == 0 {
-		fmt.Println("No moves to undo")
-		return
+		return Action{}, ErrNothingToUndo
 	}
 	lastMove := g.MoveStack[len(g.MoveStack)-1]
 	g.MoveStack = g.MoveStack[:len(g.MoveStack)-1]
-	g.Puzzle[lastMove.Row][lastMove.Col] = lastMove.OldValue
+	g.RedoStack = append(g.RedoStack, lastMove)
+	g.revert(lastMove)
+	return lastMove, nil
+}
+
+// RedoMove replays the most recently undone move, returning the action applied
+func (g *GameLogic) RedoMove() (Action, error) {
+	if len(g.RedoStack) == 0 {
+		return Action{}, ErrNothingToRedo
+	}
+	move := g.RedoStack[len(g.RedoStack)-1]
+	g.RedoStack = g.RedoStack[:len(g.RedoStack)-1]
+	g.MoveStack = append(g.MoveStack, move)
+	g.apply(move)
+	return move, nil
+}
+
+// UndoCell reverts the latest change to one cell, leaving later moves on other
+// cells in place. The change is taken out of the move stack, so a bulk pencil
+// mark move or an auto clear only loses its part for this cell, and global
+// undo carries on from a consistent history. Like any new move it clears the
+// redo stack. Numbers that would clash with the board as it is now are
+// refused with ErrUndoConflict, leaving everything as it was.
+func (g *GameLogic) UndoCell(row, col int) (Action, error) {
+	for i := len(g.MoveStack) - 1; i >= 0; i-- {
+		action := g.MoveStack[i]
+		if action.Kind != ClearMarksMove && action.Row == row && action.Col == col {
+			if action.Kind == NumberMove && g.clashes(row, col, action.OldValue) {
+				return action, ErrUndoConflict
+			}
+
+			// Marks taken out of peers by auto clear stay as a move of their
+			// own, so undoing them later still restores what they were
+			var own, peers []MarkChange
+			for _, m := range action.Marks {
+				if m.Row == row && m.Col == col {
+					own = append(own, m)
+				} else {
+					peers = append(peers, m)
+				}
+			}
+			if len(peers) == 0 {
+				g.MoveStack = append(g.MoveStack[:i], g.MoveStack[i+1:]...)
+			} else {
+				g.MoveStack[i] = Action{Kind: ClearMarksMove, Row: -1, Col: -1, Marks: peers}
+			}
+			action.Marks = own
+			g.RedoStack = nil
+			g.revert(action)
+			return action, nil
+		}
+
+		// Bulk moves can touch the cell among many others
+		for j, m := range action.Marks {
+			if m.Row != row || m.Col != col {
+				continue
+			}
+			undone := Action{
+				Kind:  ClearMarksMove,
+				Row:   -1,
+				Col:   -1,
+				Marks: []MarkChange{m},
+			}
+			rest := append(append([]MarkChange(nil), action.Marks[:j]...), action.Marks[j+1:]...)
+			if len(rest) == 0 {
+				g.MoveStack = append(g.MoveStack[:i], g.MoveStack[i+1:]...)
+			} else {
+				action.Marks = rest
+				g.MoveStack[i] = action
+			}
+			g.RedoStack = nil
+			g.revert(undone)
+			return undone, nil
+		}
+	}
+	return Action{}, ErrNothingToUndo
 }
 
-//Special Undo Specific cell.
+// clashes reports whether num is already placed in another cell sharing the
+// cell's row, column or box. Empty cells never clash.
+func (g *GameLogic) clashes(row, col, num int) bool {
+	if num == 0 {
+		return false
+	}
+	for r := 0; r < 9; r++ {
+		for c := 0; c < 9; c++ {
+			if g.Puzzle[r][c] == num && sees(Cell{row, col}, Cell{r, c}) {
+				return true
+			}
+		}
+	}
+	return false
+}
 
 // IsGridFull checks if the grid is full
 func (g *GameLogic) IsGridFull() bool {
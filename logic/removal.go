@@ -0,0 +1,126 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// RemovalStrategy decides which clues are taken out of a solved grid. The
+// symmetric strategies blank a cell together with its mirror image so the
+// givens form a pattern, while MinimalRemoval keeps going until no clue can
+// be removed without losing the unique solution.
+type RemovalStrategy int
+
+const (
+	RandomRemoval     RemovalStrategy = iota // Cells picked independently
+	RotationalRemoval                        // Unchanged by a half turn
+	MirrorRemoval                            // Unchanged by a left to right reflection
+	DiagonalRemoval                          // Unchanged by a reflection in the main diagonal
+	MinimalRemoval                           // As few clues as uniqueness allows
+
+	// UnknownRemoval marks puzzles whose clues didn't come from RemoveClues,
+	// such as pack puzzles that come with their clues. It isn't in
+	// RemovalStrategies and RemoveClues treats it like RandomRemoval.
+	UnknownRemoval
+)
+
+// RemovalStrategies lists every strategy in menu order
+var RemovalStrategies = []RemovalStrategy{RandomRemoval, RotationalRemoval, MirrorRemoval, DiagonalRemoval, MinimalRemoval}
+
+// ErrUnknownRemoval is returned for a strategy name we don't recognise
+var ErrUnknownRemoval = errors.New("unknown removal strategy")
+
+func (s RemovalStrategy) String() string {
+	switch s {
+	case RandomRemoval:
+		return "random"
+	case RotationalRemoval:
+		return "rotational"
+	case MirrorRemoval:
+		return "mirror"
+	case DiagonalRemoval:
+		return "diagonal"
+	case MinimalRemoval:
+		return "minimal"
+	case UnknownRemoval:
+		return "unknown"
+	default:
+		return fmt.Sprintf("RemovalStrategy(%d)", int(s))
+	}
+}
+
+// ParseRemovalStrategy returns the strategy with the given name
+func ParseRemovalStrategy(name string) (RemovalStrategy, error) {
+	for _, s := range RemovalStrategies {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %q", ErrUnknownRemoval, name)
+}
+
+// MarshalText lets strategies be saved by name
+func (s RemovalStrategy) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads a strategy saved by MarshalText
+func (s *RemovalStrategy) UnmarshalText(data []byte) error {
+	if string(data) == UnknownRemoval.String() {
+		*s = UnknownRemoval
+		return nil
+	}
+	parsed, err := ParseRemovalStrategy(string(data))
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// partner returns the cell blanked together with row, col. Cells on the
+// axis of a symmetry are their own partner.
+func (s RemovalStrategy) partner(row, col int) (int, int) {
+	switch s {
+	case RotationalRemoval:
+		return 8 - row, 8 - col
+	case MirrorRemoval:
+		return row, 8 - col
+	case DiagonalRemoval:
+		return col, row
+	default:
+		return row, col
+	}
+}
+
+// RemoveClues blanks cells of a solved grid following the strategy. Clues
+// are only removed while the puzzle keeps exactly one solution, so fewer
+// cells than the difficulty asks for may be blanked; MinimalRemoval ignores
+// the difficulty and removes all it can. A nil rng falls back to the global
+// math/rand source.
+func RemoveClues(rng *rand.Rand, grid *[9][9]int, strategy RemovalStrategy, difficulty int) {
+	blanks := 20 + difficulty*10 // Control how many numbers to remove based on difficulty
+	for _, cell := range randSource(rng).Perm(81) {
+		if blanks <= 0 && strategy != MinimalRemoval {
+			break
+		}
+		row, col := cell/9, cell%9
+		pRow, pCol := strategy.partner(row, col)
+		if grid[row][col] == 0 || grid[pRow][pCol] == 0 {
+			continue
+		}
+		old, pOld := grid[row][col], grid[pRow][pCol]
+		grid[row][col], grid[pRow][pCol] = 0, 0
+		if !HasUniqueSolution(*grid) {
+			// Keep the clues, removing them breaks uniqueness
+			grid[row][col], grid[pRow][pCol] = old, pOld
+			continue
+		}
+		blanks--
+		if pRow != row || pCol != col {
+			blanks--
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+)
+
+// Test the symmetric strategies blank cells in matching pairs
+func TestRemoveCluesSymmetry(t *testing.T) {
+	for _, strategy := range []RemovalStrategy{RotationalRemoval, MirrorRemoval, DiagonalRemoval} {
+		for seed := int64(1); seed <= 5; seed++ {
+			grid := [9][9]int(parseGrid(t, testAnswer))
+			RemoveClues(rand.New(rand.NewSource(seed)), &grid, strategy, 5)
+			if !HasUniqueSolution(grid) {
+				t.Fatalf("%v seed %d: puzzle has more than one solution: %v", strategy, seed, Puzzle(grid))
+			}
+			for row := 0; row < 9; row++ {
+				for col := 0; col < 9; col++ {
+					pRow, pCol := strategy.partner(row, col)
+					if (grid[row][col] == 0) != (grid[pRow][pCol] == 0) {
+						t.Fatalf("%v seed %d: cells (%d,%d) and (%d,%d) differ: %v",
+							strategy, seed, row, col, pRow, pCol, Puzzle(grid))
+					}
+				}
+			}
+		}
+	}
+}
+
+// Test minimal removal leaves no clue that could still go
+func TestRemoveCluesMinimal(t *testing.T) {
+	grid := [9][9]int(parseGrid(t, testAnswer))
+	RemoveClues(rand.New(rand.NewSource(7)), &grid, MinimalRemoval, 0)
+	if !HasUniqueSolution(grid) {
+		t.Fatalf("minimal puzzle has more than one solution: %v", Puzzle(grid))
+	}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if grid[row][col] == 0 {
+				continue
+			}
+			fewer := grid
+			fewer[row][col] = 0
+			if HasUniqueSolution(fewer) {
+				t.Errorf("clue at (%d,%d) could still be removed from %v", row, col, Puzzle(grid))
+			}
+		}
+	}
+}
+
+// Test strategies are named and parsed consistently
+func TestParseRemovalStrategy(t *testing.T) {
+	for _, s := range RemovalStrategies {
+		parsed, err := ParseRemovalStrategy(s.String())
+		if err != nil || parsed != s {
+			t.Errorf("ParseRemovalStrategy(%q) = %v, %v; want %v", s.String(), parsed, err, s)
+		}
+	}
+	if _, err := ParseRemovalStrategy("spiral"); !errors.Is(err, ErrUnknownRemoval) {
+		t.Errorf("ParseRemovalStrategy(spiral) error = %v; want ErrUnknownRemoval", err)
+	}
+
+	// Unknown layouts can be saved but not picked
+	if _, err := ParseRemovalStrategy("unknown"); err == nil {
+		t.Error("ParseRemovalStrategy(unknown) should fail")
+	}
+	var s RemovalStrategy
+	if text, _ := UnknownRemoval.MarshalText(); s.UnmarshalText(text) != nil || s != UnknownRemoval {
+		t.Errorf("UnknownRemoval read back as %v", s)
+	}
+}
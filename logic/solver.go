@@ -0,0 +1,179 @@
+package logic
+
+import (
+	"errors"
+	"math/bits"
+	"math/rand"
+)
+
+var (
+	// ErrInvalidPuzzle is returned when the clues already break the Sudoku rules
+	ErrInvalidPuzzle = errors.New("puzzle contains conflicting or out of range numbers")
+	// ErrNoSolution is returned when a puzzle cannot be completed
+	ErrNoSolution = errors.New("puzzle has no solution")
+)
+
+// allDigits is the candidate mask with bits 1-9 set
+const allDigits uint16 = 0x3FE
+
+// solver is the bitmask backtracking engine behind Solve and every other
+// caller that needs to search the grid. Each row, column and box keeps a
+// mask of the digits already used so candidates are a single bit operation.
+type solver struct {
+	grid  Puzzle
+	rows  [9]uint16
+	cols  [9]uint16
+	boxes [9]uint16
+	rng   *rand.Rand // Optional, randomizes the order digits are tried in
+	limit int        // Stop searching after this many solutions
+	count int
+	first Puzzle // First solution found
+}
+
+// newSolver prepares a solver for the puzzle, rejecting grids that already conflict
+func newSolver(p Puzzle) (*solver, error) {
+	s := &solver{grid: p, limit: 1}
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			num := p[row][col]
+			if num == 0 {
+				continue
+			}
+			if num < 0 || num > 9 {
+				return nil, ErrInvalidPuzzle
+			}
+			bit := uint16(1) << num
+			box := boxIndex(row, col)
+			if (s.rows[row]|s.cols[col]|s.boxes[box])&bit != 0 {
+				return nil, ErrInvalidPuzzle
+			}
+			s.rows[row] |= bit
+			s.cols[col] |= bit
+			s.boxes[box] |= bit
+		}
+	}
+	return s, nil
+}
+
+// boxIndex returns the 3x3 box (0-8, left to right, top to bottom) holding the cell
+func boxIndex(row, col int) int {
+	return (row/3)*3 + col/3
+}
+
+// candidates returns the mask of digits that can still go in the cell
+func (s *solver) candidates(row, col int) uint16 {
+	return allDigits &^ (s.rows[row] | s.cols[col] | s.boxes[boxIndex(row, col)])
+}
+
+func (s *solver) place(row, col, num int) {
+	bit := uint16(1) << num
+	s.grid[row][col] = num
+	s.rows[row] |= bit
+	s.cols[col] |= bit
+	s.boxes[boxIndex(row, col)] |= bit
+}
+
+func (s *solver) unplace(row, col, num int) {
+	bit := uint16(1) << num
+	s.grid[row][col] = 0
+	s.rows[row] &^= bit
+	s.cols[col] &^= bit
+	s.boxes[boxIndex(row, col)] &^= bit
+}
+
+// search fills the grid depth first, always branching on the empty cell with
+// the fewest candidates. It returns true once the solution limit is reached.
+func (s *solver) search() bool {
+	bestRow, bestCol := -1, -1
+	var bestMask uint16
+	bestCount := 10
+
+	for row := 0; row < 9 && bestCount > 1; row++ {
+		for col := 0; col < 9; col++ {
+			if s.grid[row][col] != 0 {
+				continue
+			}
+			mask := s.candidates(row, col)
+			count := bits.OnesCount16(mask)
+			if count == 0 {
+				return false // Dead end
+			}
+			if count < bestCount {
+				bestRow, bestCol, bestMask, bestCount = row, col, mask, count
+				if count == 1 {
+					break
+				}
+			}
+		}
+	}
+
+	// No empty cells left means we found a solution
+	if bestRow == -1 {
+		s.count++
+		if s.count == 1 {
+			s.first = s.grid
+		}
+		return s.count >= s.limit
+	}
+
+	digits := maskDigits(bestMask)
+	if s.rng != nil {
+		s.rng.Shuffle(len(digits), func(i, j int) {
+			digits[i], digits[j] = digits[j], digits[i]
+		})
+	}
+
+	for _, num := range digits {
+		s.place(bestRow, bestCol, num)
+		done := s.search()
+		s.unplace(bestRow, bestCol, num)
+		if done {
+			return true
+		}
+	}
+	return false
+}
+
+// maskDigits lists the digits set in a candidate mask in ascending order
+func maskDigits(mask uint16) []int {
+	digits := make([]int, 0, bits.OnesCount16(mask))
+	for num := 1; num <= 9; num++ {
+		if mask&(1<<num) != 0 {
+			digits = append(digits, num)
+		}
+	}
+	return digits
+}
+
+// Solve returns the first complete grid that agrees with the clues in p
+func Solve(p Puzzle) (Puzzle, error) {
+	s, err := newSolver(p)
+	if err != nil {
+		return p, err
+	}
+	s.search()
+	if s.count == 0 {
+		return p, ErrNoSolution
+	}
+	return s.first, nil
+}
+
+// CountSolutions counts the completions of p, stopping once limit is reached.
+// Use a limit of 2 to check that a puzzle has exactly one solution.
+func CountSolutions(p Puzzle, limit int) int {
+	if limit <= 0 {
+		return 0
+	}
+	s, err := newSolver(p)
+	if err != nil {
+		return 0
+	}
+	s.limit = limit
+	s.search()
+	return s.count
+}
+
+// HasUniqueSolution reports whether p can be completed in exactly one way
+func HasUniqueSolution(p Puzzle) bool {
+	return CountSolutions(p, 2) == 1
+}
@@ -0,0 +1,204 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// parseGrid builds a puzzle from 81 digits, '0' or '.' meaning empty
+func parseGrid(t *testing.T, s string) Puzzle {
+	t.Helper()
+	var p Puzzle
+	if len(s) != 81 {
+		t.Fatalf("grid string has %d characters; want 81", len(s))
+	}
+	for i, ch := range s {
+		if ch == '.' {
+			continue
+		}
+		if ch < '0' || ch > '9' {
+			t.Fatalf("invalid character %q in grid string", ch)
+		}
+		p[i/9][i%9] = int(ch - '0')
+	}
+	return p
+}
+
+const (
+	testPuzzle = "530070000600195000098000060800060003400803001700020006060000280000419005000080079"
+	testAnswer = "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+)
+
+// Test solving a puzzle with a single known answer
+func TestSolve(t *testing.T) {
+	puzzle := parseGrid(t, testPuzzle)
+	want := parseGrid(t, testAnswer)
+
+	got, err := Solve(puzzle)
+	if err != nil {
+		t.Fatalf("Solve returned error: %v", err)
+	}
+	if got != want {
+		t.Errorf("Solve returned %v; want %v", got, want)
+	}
+
+	// The input must not be modified
+	if puzzle != parseGrid(t, testPuzzle) {
+		t.Error("Solve modified its input")
+	}
+}
+
+// Test solving an empty and an already solved grid
+func TestSolveEdgeCases(t *testing.T) {
+	solved, err := Solve(Puzzle{})
+	if err != nil {
+		t.Fatalf("Solve(empty) returned error: %v", err)
+	}
+	g := GameLogic{Puzzle: solved}
+	if !g.IsGridValid() {
+		t.Error("Solve(empty) returned an invalid grid")
+	}
+
+	answer := parseGrid(t, testAnswer)
+	got, err := Solve(answer)
+	if err != nil || got != answer {
+		t.Errorf("Solve(solved) = %v, %v; want the same grid back", got, err)
+	}
+}
+
+// Test that broken puzzles are reported
+func TestSolveErrors(t *testing.T) {
+	conflict := parseGrid(t, testPuzzle)
+	conflict[0][2] = 5 // 5 already in row 0
+	if _, err := Solve(conflict); err != ErrInvalidPuzzle {
+		t.Errorf("Solve(conflict) error = %v; want ErrInvalidPuzzle", err)
+	}
+
+	outOfRange := parseGrid(t, testPuzzle)
+	outOfRange[0][2] = 10
+	if _, err := Solve(outOfRange); err != ErrInvalidPuzzle {
+		t.Errorf("Solve(out of range) error = %v; want ErrInvalidPuzzle", err)
+	}
+
+	// No conflicts among the clues, but R1C9 has no candidate left
+	dead := parseGrid(t, "12345678."+"........9"+strings.Repeat(".", 63))
+	if _, err := Solve(dead); err != ErrNoSolution {
+		t.Errorf("Solve(dead end) error = %v; want ErrNoSolution", err)
+	}
+}
+
+// Test solution counting and uniqueness
+func TestCountSolutions(t *testing.T) {
+	puzzle := parseGrid(t, testPuzzle)
+	if n := CountSolutions(puzzle, 2); n != 1 {
+		t.Errorf("CountSolutions(unique) = %d; want 1", n)
+	}
+	if !HasUniqueSolution(puzzle) {
+		t.Error("HasUniqueSolution(unique) = false; want true")
+	}
+
+	// An empty grid has far more solutions than any limit we use
+	if n := CountSolutions(Puzzle{}, 5); n != 5 {
+		t.Errorf("CountSolutions(empty, 5) = %d; want 5", n)
+	}
+	if HasUniqueSolution(Puzzle{}) {
+		t.Error("HasUniqueSolution(empty) = true; want false")
+	}
+
+	conflict := puzzle
+	conflict[0][2] = 5
+	if n := CountSolutions(conflict, 2); n != 0 {
+		t.Errorf("CountSolutions(conflict) = %d; want 0", n)
+	}
+}
+
+// Test that clue removal never breaks uniqueness
+func TestRemoveNumbersKeepsUniqueSolution(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		grid := [9][9]int(parseGrid(t, testAnswer))
+		RemoveNumbersFromGrid(nil, &grid, 5)
+		if !HasUniqueSolution(grid) {
+			t.Fatalf("puzzle after removal has more than one solution: %v", grid)
+		}
+	}
+}
+
+// Test that the same seed always carves the same puzzle from the same grid
+func TestSeededRemovalIsReproducible(t *testing.T) {
+	carve := func(seed int64) [9][9]int {
+		rng := rand.New(rand.NewSource(seed))
+		grid := GetRandomPuzzle(rng, []Puzzle{parseGrid(t, testAnswer), GenerateSolvedGrid(rng)})
+		ShuffleAsh(rng, &grid)
+		RemoveNumbersFromGrid(rng, &grid, 3)
+		return grid
+	}
+	if a, b := carve(99), carve(99); a != b {
+		t.Errorf("seed 99 gave %v then %v; want the same puzzle", Puzzle(a), Puzzle(b))
+	}
+	if a, b := carve(1), carve(2); a == b {
+		t.Errorf("seeds 1 and 2 both gave %v", Puzzle(a))
+	}
+}
+
+// Test that generated grids are complete, valid and vary with the seed
+func TestGenerateSolvedGrid(t *testing.T) {
+	seen := make(map[Puzzle]bool)
+	for seed := int64(1); seed <= 10; seed++ {
+		grid := GenerateSolvedGrid(rand.New(rand.NewSource(seed)))
+		g := GameLogic{Puzzle: grid}
+		if !g.IsGridValid() {
+			t.Fatalf("seed %d: generated grid is not valid: %v", seed, grid)
+		}
+		seen[grid] = true
+	}
+	if len(seen) < 10 {
+		t.Errorf("generated %d distinct grids from 10 seeds; want 10", len(seen))
+	}
+
+	// The same seed must give the same grid
+	a := GenerateSolvedGrid(rand.New(rand.NewSource(42)))
+	b := GenerateSolvedGrid(rand.New(rand.NewSource(42)))
+	if a != b {
+		t.Error("GenerateSolvedGrid is not reproducible for a fixed seed")
+	}
+}
+
+// Test the generator hits its target and doesn't depend on the worker count
+func TestGenerator(t *testing.T) {
+	spec := Spec{
+		Removal:  RotationalRemoval,
+		Clues:    5,
+		Accept:   func(r Rating) bool { return r.Hardest > NakedSingle },
+		Attempts: 20,
+	}
+	one, rating, err := Generator{Workers: 1}.Generate(context.Background(), 31, spec)
+	if err != nil {
+		t.Fatalf("Generate returned error: %v", err)
+	}
+	if !HasUniqueSolution(one) || rating != Grade(one) {
+		t.Errorf("generated %v rated %+v; want a unique puzzle with its own rating", one, rating)
+	}
+	if rating.Hardest <= NakedSingle {
+		t.Errorf("generated puzzle needs only %v; want something harder", rating.Hardest)
+	}
+	many, _, err := Generator{Workers: 8}.Generate(context.Background(), 31, spec)
+	if err != nil || many != one {
+		t.Errorf("8 workers gave %v, %v; want %v as with one worker", many, err, one)
+	}
+
+	// Packs are shuffled rather than replaced
+	spec.Sources = []Puzzle{parseGrid(t, testAnswer)}
+	fromPack, _, err := Generator{}.Generate(context.Background(), 31, spec)
+	if err != nil || !HasUniqueSolution(fromPack) {
+		t.Errorf("Generate from a pack gave %v, %v; want a unique puzzle", fromPack, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := (Generator{}).Generate(ctx, 31, spec); !errors.Is(err, context.Canceled) {
+		t.Errorf("Generate with a cancelled context returned %v; want context.Canceled", err)
+	}
+}
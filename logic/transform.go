@@ -0,0 +1,178 @@
+package logic
+
+import "math/rand"
+
+// Transform is a validity preserving change to a grid: digits are relabelled,
+// bands and stacks are reordered along with the rows and columns inside them,
+// and the grid is optionally transposed. Every rotation and reflection of the
+// board can be written this way. A Transform is plain data, so storing it is
+// enough to reproduce a variant, and Invert undoes it.
+type Transform struct {
+	Digits    [9]int    // Digits[d-1] is the digit d becomes
+	Bands     [3]int    // Bands[i] is the band moved to position i
+	Rows      [3][3]int // Rows[i][j] is the row of band Bands[i] moved to position j
+	Stacks    [3]int    // Stacks[i] is the stack moved to position i
+	Cols      [3][3]int // Cols[i][j] is the column of stack Stacks[i] moved to position j
+	Transpose bool      // Rows and columns are swapped after reordering
+}
+
+// identityOrder leaves three bands, stacks or lines where they are
+var identityOrder = [3]int{0, 1, 2}
+
+// reversedOrder swaps the first and last of three bands, stacks or lines
+var reversedOrder = [3]int{2, 1, 0}
+
+// IdentityTransform returns the transform that changes nothing
+func IdentityTransform() Transform {
+	t := Transform{
+		Bands:  identityOrder,
+		Rows:   [3][3]int{identityOrder, identityOrder, identityOrder},
+		Stacks: identityOrder,
+		Cols:   [3][3]int{identityOrder, identityOrder, identityOrder},
+	}
+	for d := range t.Digits {
+		t.Digits[d] = d + 1
+	}
+	return t
+}
+
+// Rotate returns the transform turning the grid clockwise by the given
+// number of quarter turns
+func Rotate(quarterTurns int) Transform {
+	t := IdentityTransform()
+	switch ((quarterTurns % 4) + 4) % 4 {
+	case 1:
+		t.reverseRows()
+		t.Transpose = true
+	case 2:
+		t.reverseRows()
+		t.reverseCols()
+	case 3:
+		t.reverseCols()
+		t.Transpose = true
+	}
+	return t
+}
+
+// ReflectHorizontal returns the transform mirroring the grid left to right
+func ReflectHorizontal() Transform {
+	t := IdentityTransform()
+	t.reverseCols()
+	return t
+}
+
+// ReflectVertical returns the transform mirroring the grid top to bottom
+func ReflectVertical() Transform {
+	t := IdentityTransform()
+	t.reverseRows()
+	return t
+}
+
+// TransposeTransform returns the transform mirroring the grid along its main diagonal
+func TransposeTransform() Transform {
+	t := IdentityTransform()
+	t.Transpose = true
+	return t
+}
+
+func (t *Transform) reverseRows() {
+	t.Bands = reversedOrder
+	t.Rows = [3][3]int{reversedOrder, reversedOrder, reversedOrder}
+}
+
+func (t *Transform) reverseCols() {
+	t.Stacks = reversedOrder
+	t.Cols = [3][3]int{reversedOrder, reversedOrder, reversedOrder}
+}
+
+// RandomTransform picks a transform uniformly from the whole symmetry group.
+// A nil rng falls back to the global math/rand source.
+func RandomTransform(rng *rand.Rand) Transform {
+	rng = randSource(rng)
+
+	var t Transform
+	for d, num := range rng.Perm(9) {
+		t.Digits[d] = num + 1
+	}
+	t.Bands = perm3(rng)
+	t.Stacks = perm3(rng)
+	for i := 0; i < 3; i++ {
+		t.Rows[i] = perm3(rng)
+		t.Cols[i] = perm3(rng)
+	}
+	t.Transpose = rng.Intn(2) == 1
+	return t
+}
+
+func perm3(rng *rand.Rand) [3]int {
+	var order [3]int
+	copy(order[:], rng.Perm(3))
+	return order
+}
+
+// sourceLine returns the line moved to position i by a band or stack order
+// and the line orders within them
+func sourceLine(blocks [3]int, lines [3][3]int, i int) int {
+	return blocks[i/3]*3 + lines[i/3][i%3]
+}
+
+// Apply returns the transformed grid. Empty cells stay empty.
+func (t Transform) Apply(p Puzzle) Puzzle {
+	var out Puzzle
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			num := p[sourceLine(t.Bands, t.Rows, row)][sourceLine(t.Stacks, t.Cols, col)]
+			if num != 0 {
+				num = t.Digits[num-1]
+			}
+			if t.Transpose {
+				out[col][row] = num
+			} else {
+				out[row][col] = num
+			}
+		}
+	}
+	return out
+}
+
+// Invert returns the transform that undoes t
+func (t Transform) Invert() Transform {
+	var inv Transform
+	for d, num := range t.Digits {
+		inv.Digits[num-1] = d + 1
+	}
+
+	bands, rows := invertOrder(t.Bands, t.Rows)
+	stacks, cols := invertOrder(t.Stacks, t.Cols)
+	if t.Transpose {
+		// Transposing back first means the column order now acts on rows
+		bands, rows, stacks, cols = stacks, cols, bands, rows
+	}
+	inv.Bands, inv.Rows = bands, rows
+	inv.Stacks, inv.Cols = stacks, cols
+	inv.Transpose = t.Transpose
+	return inv
+}
+
+// invertOrder inverts a band or stack order together with the line orders
+// inside each block
+func invertOrder(blocks [3]int, lines [3][3]int) ([3]int, [3][3]int) {
+	var invBlocks [3]int
+	var invLines [3][3]int
+	for i, b := range blocks {
+		invBlocks[b] = i
+		for j, l := range lines[i] {
+			invLines[b][l] = j
+		}
+	}
+	return invBlocks, invLines
+}
+
+// Function to shuffle the puzzle. It applies a random transform from the
+// full symmetry group and returns it, so the variant can be reproduced.
+// A nil rng falls back to the global math/rand source.
+func ShuffleAsh(rng *rand.Rand, grid *[9][9]int) Transform {
+	t := RandomTransform(rng)
+	*grid = t.Apply(*grid)
+	return t
+}
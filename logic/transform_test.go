@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test transforms keep grids valid and can be undone
+func TestTransformInvert(t *testing.T) {
+	answer := parseGrid(t, testAnswer)
+	puzzle := parseGrid(t, testPuzzle)
+	for seed := int64(1); seed <= 50; seed++ {
+		tr := RandomTransform(rand.New(rand.NewSource(seed)))
+
+		variant := tr.Apply(answer)
+		if !solves(variant, Puzzle{}) {
+			t.Fatalf("seed %d: %+v broke the grid: %v", seed, tr, variant)
+		}
+		if back := tr.Invert().Apply(variant); back != answer {
+			t.Fatalf("seed %d: inverting %+v gave %v; want %v", seed, tr, back, answer)
+		}
+
+		// Clues move with their cells, and the variant stays uniquely solvable
+		disguised := tr.Apply(puzzle)
+		if solution, err := Solve(disguised); err != nil || solution != variant {
+			t.Fatalf("seed %d: variant puzzle solves to %v, %v; want %v", seed, solution, err, variant)
+		}
+	}
+
+	// The same seed gives the same transform
+	a := RandomTransform(rand.New(rand.NewSource(7)))
+	b := RandomTransform(rand.New(rand.NewSource(7)))
+	if a != b {
+		t.Errorf("RandomTransform differs for the same seed: %+v and %+v", a, b)
+	}
+}
+
+// Test the named rotations and reflections move cells where expected
+func TestTransformSymmetries(t *testing.T) {
+	var p Puzzle
+	p[0][1] = 5 // Top row, second column
+
+	tests := []struct {
+		name     string
+		tr       Transform
+		row, col int
+	}{
+		{"identity", IdentityTransform(), 0, 1},
+		{"quarter turn", Rotate(1), 1, 8},
+		{"half turn", Rotate(2), 8, 7},
+		{"three quarter turn", Rotate(3), 7, 0},
+		{"mirror left to right", ReflectHorizontal(), 0, 7},
+		{"mirror top to bottom", ReflectVertical(), 8, 1},
+		{"transpose", TransposeTransform(), 1, 0},
+	}
+	for _, tt := range tests {
+		got := tt.tr.Apply(p)
+		if got[tt.row][tt.col] != 5 {
+			t.Errorf("%s moved the clue to %v; want R%dC%d", tt.name, got, tt.row+1, tt.col+1)
+		}
+	}
+
+	q := p
+	for i := 0; i < 4; i++ {
+		q = Rotate(1).Apply(q)
+	}
+	if q != p {
+		t.Errorf("four quarter turns gave %v; want the original", q)
+	}
+}
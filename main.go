@@ -2,10 +2,13 @@ package main
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"image/color"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/afroash/mygame/logic"
 
@@ -38,6 +41,11 @@ const (
 	MainMenu GameState = iota
 	DifficultyMenu
 	Playing
+	LoadMenu
+	Paused
+	StatsMenu
+	PackMenu
+	SeedMenu
 )
 
 type DifficultyLevel int
@@ -48,6 +56,9 @@ const (
 	Hard
 )
 
+// Number of puzzles to generate while looking for one graded at the selected difficulty
+const maxGradeAttempts = 40
+
 type StatusMessage struct {
 	text      string
 	color     color.RGBA
@@ -72,23 +83,53 @@ var (
 
 // Game struct
 type Game struct {
-	cursorX          int // X position of the game box
-	cursorY          int // Y position of the game box
-	Puzzle           *logic.GameLogic
-	logic            *logic.GameLogic
-	state            GameState
-	difficulty       DifficultyLevel
-	selected         int
-	drawer           *DrawHandler
-	shoudlExit       bool
-	showWinMessage   bool
-	messageTimer     int
-	statusMessage    StatusMessage
-	specialEnterMode bool
-	pencilMarks      [9][9]map[int]bool // Pencil marks for each cell (possible numbers)
-}
-
-func NewGame() *Game {
+	cursorX            int // X position of the game box
+	cursorY            int // Y position of the game box
+	logic              *logic.GameLogic
+	state              GameState
+	difficulty         DifficultyLevel       // Level picked for new games
+	level              DifficultyLevel       // Level of the current game, which its stats count towards
+	removal            logic.RemovalStrategy // How clues are taken out of new puzzles
+	layout             logic.RemovalStrategy // How clues were taken out of the current game
+	selected           int
+	drawer             *DrawHandler
+	shoudlExit         bool
+	showWinMessage     bool
+	messageTimer       int
+	statusMessage      StatusMessage
+	specialEnterMode   bool
+	centreMode         bool         // Help mode enters centre marks rather than pencil marks
+	autoClear          bool         // Placing a number removes it from its peers' pencil marks
+	markStyle          markStyle    // How pencil marks are laid out in their cells
+	packs              []puzzlePack // Loaded puzzle packs, the embedded one first
+	useDefaultPack     bool         // New Game draws from the embedded pack too
+	progress           *packProgress
+	packKey            string // Pack the current puzzle came from, empty for New Game puzzles
+	packPuzzleID       string
+	seed               int64        // Seed the current game was built from, 0 if it has none
+	daily              string       // Date of the daily puzzle being played, empty for other games
+	seedBuffer         []rune       // Seed being typed on the seed screen
+	queue              *puzzleQueue // Puzzles generated ahead for New Game
+	pending            *pendingGame // Puzzle being generated for a menu, nil if none
+	rating             logic.Rating // Grader rating of the current puzzle
+	hint               *logic.Step  // Hint currently highlighted on the board
+	hintEliminations   [9][9]uint16 // Candidates already ruled out by hints, as bit masks
+	clock              gameClock    // Time spent on the current puzzle
+	savePath           string       // Autosave file, saving is off when empty
+	canContinue        bool         // A game in progress or an autosave can be resumed
+	slots              *slotManager // Named save slots, nil if the config dir is unavailable
+	slotList           []slotInfo   // Slots shown on the load screen
+	slotName           string       // Slot the current game is saved to
+	renaming           bool         // Typing a new name for the selected slot
+	renameBuffer       []rune
+	confirmDelete      bool        // Delete was pressed once on the selected slot
+	stats              *statsStore // Local statistics, nil if they can't be stored
+	completionRecorded bool        // The current puzzle's completion has been counted
+}
+
+// NewGame sets up the game with the command line options, adding the ones
+// from the config file
+func NewGame(flags options) *Game {
 	// Initialize font
 	s, err := text.NewGoTextFaceSource(bytes.NewReader(fonts.MPlus1pRegular_ttf))
 	if err != nil {
@@ -100,16 +141,75 @@ func NewGame() *Game {
 		cursorY:    gridSize / 2,
 		state:      MainMenu,
 		shoudlExit: false,
+		removal:    defaultRemoval,
 		statusMessage: StatusMessage{
 			timer:     0,
 			isVisible: false,
 		},
 	}
 
-	// Initialize pencil marks maps
-	for i := 0; i < 9; i++ {
-		for j := 0; j < 9; j++ {
-			game.pencilMarks[i][j] = make(map[int]bool)
+	// Look for a game to continue
+	if path, err := autosavePath(); err != nil {
+		log.Printf("Saving disabled: %v", err)
+	} else {
+		game.savePath = path
+		if _, err := os.Stat(path); err == nil {
+			game.canContinue = true
+		}
+	}
+
+	if slots, err := defaultSlotManager(); err == nil {
+		game.slots = slots
+	}
+
+	// Load the statistics, leaving them off rather than overwriting a file we can't read
+	if path, err := statsPath(); err == nil {
+		if stats, err := loadStats(path); err != nil {
+			log.Printf("Statistics disabled: %v", err)
+		} else {
+			game.stats = stats
+		}
+	}
+
+	// Load the puzzle packs, reporting problems on the menu rather than quitting
+	opts := flags
+	if path, err := configPath(); err == nil {
+		config, err := loadConfig(path)
+		if err != nil {
+			log.Printf("Ignoring config: %v", err)
+		}
+		opts = config.merge(flags)
+	}
+	game.autoClear = opts.AutoClear
+	if opts.MarkStyle != "" {
+		if style, err := parseMarkStyle(opts.MarkStyle); err != nil {
+			log.Printf("Ignoring config: %v", err)
+		} else {
+			game.markStyle = style
+		}
+	}
+	if flags.Difficulty != "" {
+		if level, err := parseDifficulty(flags.Difficulty); err != nil {
+			log.Printf("Ignoring difficulty: %v", err)
+		} else {
+			game.difficulty = level
+		}
+	}
+	if flags.Symmetry != "" {
+		if removal, err := logic.ParseRemovalStrategy(flags.Symmetry); err != nil {
+			log.Printf("Ignoring symmetry: %v", err)
+		} else {
+			game.removal = removal
+		}
+	}
+	game.loadPuzzleSources(opts)
+	game.queue = newPuzzleQueue(game.packPuzzles())
+	game.queue.selectKey(queueKey{game.difficulty, game.removal})
+	if path, err := progressPath(); err == nil {
+		if progress, err := loadProgress(path); err != nil {
+			log.Printf("Pack progress disabled: %v", err)
+		} else {
+			game.progress = progress
 		}
 	}
 
@@ -138,33 +238,75 @@ func (g *Game) updateStatusMessage() {
 }
 
 func (g *Game) Update() error {
+	// Closing the window counts as exiting so the game still gets saved
+	if ebiten.IsWindowBeingClosed() {
+		g.shoudlExit = true
+	}
+
 	// check if the game should exit
 	if g.shoudlExit {
+		if err := g.autosave(); err != nil {
+			log.Printf("Error saving game: %v", err)
+		}
 		return ebiten.Termination
 	}
 
+	//update the status message timer
+	g.updateStatusMessage()
+	defer g.updateClock()
+	g.checkQueue()
+
 	switch g.state {
 	case MainMenu:
 		g.handleMainMenu()
 	case DifficultyMenu:
 		g.handleDifficultyMenu()
+	case LoadMenu:
+		g.handleLoadMenu()
 	case Playing:
 		if g.logic != nil {
 			g.handlePlayingInput()
 		}
+	case Paused:
+		g.handlePausedInput()
+	case PackMenu:
+		g.handlePackMenu()
+	case SeedMenu:
+		g.handleSeedMenu()
 	}
 
 	// Global Exit
 	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
 		switch g.state {
-		case Playing:
-			// If in playing state, go back to main menu
+		case Playing, Paused:
+			// If in playing state, save and go back to main menu
+			g.clock.Stop()
+			if err := g.autosave(); err != nil {
+				g.showStatus(fmt.Sprintf("Error saving game: %v", err), errorMessage, longMessageDuration)
+			}
 			g.state = MainMenu
 			g.selected = 0
 		case DifficultyMenu:
 			// If in difficulty menu, go back to main menu
 			g.state = MainMenu
-			g.selected = 1
+			g.selectMenuOption(menuDifficulty)
+		case StatsMenu:
+			g.state = MainMenu
+			g.selectMenuOption(menuStats)
+		case PackMenu:
+			g.state = MainMenu
+			g.selectMenuOption(menuPacks)
+		case SeedMenu:
+			g.state = MainMenu
+			g.selectMenuOption(menuSeed)
+		case LoadMenu:
+			// Cancel a rename, or go back to main menu
+			if g.renaming {
+				g.renaming = false
+			} else {
+				g.state = MainMenu
+				g.selectMenuOption(menuLoad)
+			}
 		case MainMenu:
 			// If in main menu, exit the game
 			g.shoudlExit = true
@@ -174,18 +316,59 @@ func (g *Game) Update() error {
 	return nil
 }
 
+// Main menu entries
+const (
+	menuContinue   = "Continue"
+	menuNewGame    = "New Game"
+	menuDaily      = "Daily"
+	menuPacks      = "Puzzle Packs"
+	menuSeed       = "Play Seed"
+	menuLoad       = "Load Game"
+	menuDifficulty = "Difficulty"
+	menuStats      = "Statistics"
+	menuExit       = "Exit"
+)
+
+// mainMenuOptions lists the main menu entries, offering Continue only when
+// there is a game to go back to
+func (g *Game) mainMenuOptions() []string {
+	options := []string{menuNewGame, menuDaily, menuSeed, menuPacks, menuLoad, menuDifficulty, menuStats, menuExit}
+	if g.canContinue || (g.logic != nil && g.logic.GetGameStatus() != logic.Completed) {
+		options = append([]string{menuContinue}, options...)
+	}
+	return options
+}
+
+// selectMenuOption highlights the named main menu entry
+func (g *Game) selectMenuOption(name string) {
+	for i, option := range g.mainMenuOptions() {
+		if option == name {
+			g.selected = i
+			return
+		}
+	}
+	g.selected = 0
+}
+
 func (g *Game) handleMainMenu() {
+	options := g.mainMenuOptions()
+	if g.selected >= len(options) {
+		g.selected = 0
+	}
+
 	// Only process one key press per frame for smoother navigation
 	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
-		g.selected = (g.selected + 1) % 3
+		g.selected = (g.selected + 1) % len(options)
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
 		g.selected = (g.selected - 1)
 		if g.selected < 0 {
-			g.selected = 2
+			g.selected = len(options) - 1
 		}
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
-		switch g.selected {
-		case 0: // New Game
+		switch options[g.selected] {
+		case menuContinue:
+			g.continueGame()
+		case menuNewGame:
 			if g.difficulty == 0 {
 				// If difficulty hasn't been set, go to difficulty menu first
 				g.state = DifficultyMenu
@@ -194,10 +377,20 @@ func (g *Game) handleMainMenu() {
 				// If difficulty is already set, start the game
 				g.startGame()
 			}
-		case 1: // Difficulty
+		case menuDaily:
+			g.startDaily(time.Now())
+		case menuSeed:
+			g.openSeedMenu()
+		case menuPacks:
+			g.openPackMenu()
+		case menuLoad:
+			g.openLoadMenu()
+		case menuDifficulty:
 			g.state = DifficultyMenu
 			g.selected = 0
-		case 2: // Exit
+		case menuStats:
+			g.state = StatsMenu
+		case menuExit:
 			g.shoudlExit = true // Exit the game
 		}
 	}
@@ -211,25 +404,112 @@ func (g *Game) handleDifficultyMenu() {
 		if g.selected < 0 {
 			g.selected = 2
 		}
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) {
+		g.changeRemoval(-1)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) {
+		g.changeRemoval(1)
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
 		g.difficulty = DifficultyLevel(g.selected)
 		g.startGame()
 	} else if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
 		g.state = MainMenu
-		g.selected = 1 // Select "Difficulty" option when returning
+		g.selectMenuOption(menuDifficulty) // Select "Difficulty" option when returning
 	}
 }
 
+// handleLoadMenu lets the player open, rename and delete save slots
+func (g *Game) handleLoadMenu() {
+	if g.renaming {
+		g.handleRenameInput()
+		return
+	}
+	if len(g.slotList) == 0 {
+		return
+	}
+	slot := g.slotList[g.selected]
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		g.selected = (g.selected + 1) % len(g.slotList)
+		g.confirmDelete = false
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		g.selected = (g.selected - 1)
+		if g.selected < 0 {
+			g.selected = len(g.slotList) - 1
+		}
+		g.confirmDelete = false
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		s, err := g.slots.Load(slot.Name)
+		if err != nil {
+			g.showStatus(fmt.Sprintf("Could not load %q: %v", slot.Name, err), errorMessage, longMessageDuration)
+			return
+		}
+		g.restore(s)
+		g.showStatus(fmt.Sprintf("Loaded %q", slot.Name), infoMessage, shortMessageDuration)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyR) {
+		g.renaming = true
+		g.renameBuffer = []rune(slot.Name)
+		g.confirmDelete = false
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyDelete) || inpututil.IsKeyJustPressed(ebiten.KeyX) {
+		// Ask for a second press before deleting
+		if !g.confirmDelete {
+			g.confirmDelete = true
+			g.showStatus(fmt.Sprintf("Press DEL or X again to delete %q", slot.Name), warningMessage, longMessageDuration)
+			return
+		}
+		g.confirmDelete = false
+		if err := g.slots.Delete(slot.Name); err != nil {
+			g.showStatus(err.Error(), errorMessage, longMessageDuration)
+			return
+		}
+		if g.slotName == slot.Name {
+			g.slotName = ""
+		}
+		g.refreshSlots()
+		g.showStatus(fmt.Sprintf("Deleted %q", slot.Name), infoMessage, shortMessageDuration)
+	}
+}
+
+// Longest name a save slot can be given
+const maxSlotNameLength = 30
+
+// handleRenameInput edits the new name of the selected slot
+func (g *Game) handleRenameInput() {
+	g.renameBuffer = ebiten.AppendInputChars(g.renameBuffer)
+	if len(g.renameBuffer) > maxSlotNameLength {
+		g.renameBuffer = g.renameBuffer[:maxSlotNameLength]
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(g.renameBuffer) > 0 {
+		g.renameBuffer = g.renameBuffer[:len(g.renameBuffer)-1]
+	}
+	if !inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		return
+	}
+
+	oldName := g.slotList[g.selected].Name
+	newName := strings.TrimSpace(string(g.renameBuffer))
+	if err := g.slots.Rename(oldName, newName); err != nil {
+		g.showStatus(err.Error(), errorMessage, longMessageDuration)
+		return
+	}
+	if g.slotName == oldName {
+		g.slotName = newName
+	}
+	g.renaming = false
+	g.refreshSlots()
+}
+
 // handlePlayingInput will handle the input when the game is in the Playing state
 func (g *Game) handlePlayingInput() {
-	//update the status message timer
-	g.updateStatusMessage()
-
 	// Handle  progress check [P] key
 	if inpututil.IsKeyJustPressed(ebiten.KeyP) {
 		g.CheckProgress()
 
 	}
+
+	// Handle hint [I] key
+	if inpututil.IsKeyJustPressed(ebiten.KeyI) {
+		g.ShowHint()
+	}
 	// Move the cursor
 	if inpututil.IsKeyJustPressed(ebiten.KeyUp) || inpututil.IsKeyJustPressed(ebiten.KeyW) {
 		if g.cursorY > 0 {
@@ -253,98 +533,115 @@ func (g *Game) handlePlayingInput() {
 	}
 
 	// Handle number input
-	if g.logic.Puzzle[g.cursorY][g.cursorX] == 0 {
-		for i := ebiten.Key0; i <= ebiten.Key9; i++ {
-			if inpututil.IsKeyJustPressed(i) {
-				num := int(i - ebiten.Key0)
-				if num == 0 {
-					continue // Skip 0 as it's not a valid input
-				}
-
-				// Handle help mode (pencil marks)
-				if g.specialEnterMode {
-					// Toggle pencil mark
-					if g.pencilMarks[g.cursorY][g.cursorX][num] {
-						// Remove pencil mark
-						delete(g.pencilMarks[g.cursorY][g.cursorX], num)
-					} else {
-						// Add pencil mark if we have less than 4
-						if len(g.pencilMarks[g.cursorY][g.cursorX]) < 4 {
-							g.pencilMarks[g.cursorY][g.cursorX][num] = true
-						} else {
-							g.showStatus("Maximum 4 pencil marks per cell", warningMessage, shortMessageDuration)
-						}
-					}
-				} else {
-					// Normal mode - enter final number
-					if g.isNumValid(g.cursorY, g.cursorX, num) {
-						// Clear pencil marks when entering final number
-						g.pencilMarks[g.cursorY][g.cursorX] = make(map[int]bool)
-
-						g.logic.Puzzle[g.cursorY][g.cursorX] = num
-						g.logic.MoveStack = append(g.logic.MoveStack, logic.Action{
-							Row:      g.cursorY,
-							Col:      g.cursorX,
-							OldValue: 0,
-							NewValue: num,
-						})
-
-						// Check win condition
-						if g.logic.IsGridFull() {
-							if g.logic.IsGridValid() {
-								g.showWinMessage = true
-								g.messageTimer = longMessageDuration
-								g.showStatus("Puzzle Completed!", successMessage, longMessageDuration)
-							}
-						}
-					} else {
-						// Show error message for invalid number
-						g.showStatus(fmt.Sprintf("Invalid number: %d cannot be placed here", num),
-							errorMessage, normalMessageDuration)
-					}
-				}
-			}
+	for i := ebiten.Key0; i <= ebiten.Key9; i++ {
+		if !inpututil.IsKeyJustPressed(i) {
+			continue
 		}
-	} else {
-		// Handle trying to modify fixed numbers
-		for i := ebiten.Key0; i <= ebiten.Key9; i++ {
-			if inpututil.IsKeyJustPressed(i) {
-				if g.specialEnterMode {
-					// In help mode, allow pencil marks even on filled cells (for reference)
-					num := int(i - ebiten.Key0)
-					if num == 0 {
-						continue
-					}
-					// Toggle pencil mark
-					if g.pencilMarks[g.cursorY][g.cursorX][num] {
-						delete(g.pencilMarks[g.cursorY][g.cursorX], num)
-					} else {
-						if len(g.pencilMarks[g.cursorY][g.cursorX]) < 4 {
-							g.pencilMarks[g.cursorY][g.cursorX][num] = true
-						} else {
-							g.showStatus("Maximum 4 pencil marks per cell", warningMessage, shortMessageDuration)
-						}
-					}
-				} else {
-					g.showStatus("Cannot modify fixed numbers", warningMessage, shortMessageDuration)
-				}
+		num := int(i - ebiten.Key0)
+
+		if g.specialEnterMode {
+			// Handle help mode (pencil marks), allowed on filled cells too for reference
+			if num != 0 {
+				g.togglePencilMark(num)
 			}
+		} else if g.logic.IsGiven(g.cursorY, g.cursorX) {
+			// Handle trying to modify fixed numbers
+			g.showStatus("Cannot modify fixed numbers", warningMessage, shortMessageDuration)
+		} else if num == 0 {
+			g.eraseCell()
+		} else {
+			g.enterNumber(num)
+		}
+	}
+
+	// Handle erasing a player entry
+	if inpututil.IsKeyJustPressed(ebiten.KeyDelete) && !g.specialEnterMode {
+		if g.logic.IsGiven(g.cursorY, g.cursorX) {
+			g.showStatus("Cannot modify fixed numbers", warningMessage, shortMessageDuration)
+		} else {
+			g.eraseCell()
+		}
+	}
+
+	// Handle undo and redo (Shift+Z or Y)
+	shift := ebiten.IsKeyPressed(ebiten.KeyShift)
+	if inpututil.IsKeyJustPressed(ebiten.KeyY) || (shift && inpututil.IsKeyJustPressed(ebiten.KeyZ)) {
+		g.redo()
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyZ) || inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
+		g.undo()
+	}
+
+	// Undo the latest change to the cell under the cursor [U]
+	if inpututil.IsKeyJustPressed(ebiten.KeyU) {
+		if action, err := g.logic.UndoCell(g.cursorY, g.cursorX); errors.Is(err, logic.ErrUndoConflict) {
+			g.showStatus(fmt.Sprintf("Cannot undo: %d is already in this row, column or box", action.OldValue),
+				warningMessage, normalMessageDuration)
+		} else if err != nil {
+			g.showStatus("Nothing to undo in this cell", warningMessage, shortMessageDuration)
+		} else {
+			g.hint = nil
 		}
 	}
 
-	// Handle undo
-	if inpututil.IsKeyJustPressed(ebiten.KeyZ) || inpututil.IsKeyJustPressed(ebiten.KeyBackspace) {
-		g.logic.UndoMove()
+	// Pause the game and its clock [Tab]
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) {
+		g.pause()
+		return
+	}
+
+	// Save to the game's save slot [F5]
+	if inpututil.IsKeyJustPressed(ebiten.KeyF5) {
+		g.saveToSlot()
 	}
 
-	// Enable Special Enter mode to enter a temp number in one of the current cell corners.
+	// Clear every pencil mark on the board [C], undoable as one move
+	if inpututil.IsKeyJustPressed(ebiten.KeyC) {
+		if !g.logic.ClearPencilMarks() {
+			g.showStatus("No pencil marks to clear", warningMessage, shortMessageDuration)
+		}
+	}
+
+	// Pencil in every candidate of every empty cell [F], undoable as one move
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		if g.logic.FillCandidates() {
+			g.hint = nil
+		} else {
+			g.showStatus("Candidates are already filled in", warningMessage, shortMessageDuration)
+		}
+	}
+
+	// Toggle removing placed numbers from the pencil marks they rule out [E]
+	if inpututil.IsKeyJustPressed(ebiten.KeyE) {
+		g.autoClear = !g.autoClear
+		state := "OFF"
+		if g.autoClear {
+			state = "ON"
+		}
+		g.showStatus("Auto clear pencil marks: "+state, infoMessage, shortMessageDuration)
+	}
+
+	// Switch between the grid and corner pencil mark notations [M]
+	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
+		g.markStyle = (g.markStyle + 1) % (cornerMarks + 1)
+		g.showStatus(fmt.Sprintf("Pencil marks: %v notation", g.markStyle), infoMessage, shortMessageDuration)
+	}
+
+	// Enable Special Enter mode to enter pencil marks in the current cell.
 	if inpututil.IsKeyJustPressed(ebiten.KeyH) {
 		g.specialEnterMode = true
+		g.centreMode = false
+	}
+
+	// Special Enter mode for the centre marks [O]
+	if inpututil.IsKeyJustPressed(ebiten.KeyO) {
+		g.specialEnterMode = true
+		g.centreMode = true
 	}
 
 	// Disable Special Enter mode
 	if inpututil.IsKeyJustPressed(ebiten.KeyN) {
 		g.specialEnterMode = false
+		g.centreMode = false
 	}
 
 	// Handle win message timer
@@ -370,6 +667,97 @@ func (g *Game) handlePlayingInput() {
 	}
 }
 
+// togglePencilMark adds or removes a pencil mark in the cell under the
+// cursor, recorded so it can be undone. Cells take all nine marks.
+func (g *Game) togglePencilMark(num int) {
+	if g.centreMode {
+		g.logic.ToggleCentreMark(g.cursorY, g.cursorX, num)
+		return
+	}
+	g.logic.TogglePencilMark(g.cursorY, g.cursorX, num)
+}
+
+// enterNumber places a player's number in the cell under the cursor,
+// overwriting any earlier entry
+func (g *Game) enterNumber(num int) {
+	if g.logic.Puzzle[g.cursorY][g.cursorX] == num {
+		return
+	}
+	if !g.isNumValid(g.cursorY, g.cursorX, num) {
+		g.recordStat(func(l *levelStats) { l.Mistakes++ })
+
+		// Show error message for invalid number
+		g.showStatus(fmt.Sprintf("Invalid number: %d cannot be placed here", num),
+			errorMessage, normalMessageDuration)
+		return
+	}
+
+	setCell := g.logic.SetCell
+	if g.autoClear {
+		setCell = g.logic.SetCellAndEliminate
+	}
+	if err := setCell(g.cursorY, g.cursorX, num); err != nil {
+		g.showStatus("Cannot modify fixed numbers", warningMessage, shortMessageDuration)
+		return
+	}
+	// SetCell clears the pencil marks as part of the same move, along with
+	// the peers' ones in auto clear mode
+	g.hint = nil
+
+	// Check win condition
+	if g.logic.IsGridFull() {
+		if g.logic.IsGridValid() {
+			g.showWinMessage = true
+			g.messageTimer = longMessageDuration
+			g.showStatus("Puzzle Completed!", successMessage, longMessageDuration)
+			g.recordCompletion()
+		}
+	}
+}
+
+// eraseCell clears a player's number from the cell under the cursor
+func (g *Game) eraseCell() {
+	if g.logic.Puzzle[g.cursorY][g.cursorX] == 0 {
+		return
+	}
+	if err := g.logic.SetCell(g.cursorY, g.cursorX, 0); err != nil {
+		g.showStatus("Cannot modify fixed numbers", warningMessage, shortMessageDuration)
+		return
+	}
+	g.hint = nil
+}
+
+// undo reverts the last move and moves the cursor to the cell it changed
+func (g *Game) undo() {
+	action, err := g.logic.UndoMove()
+	if err != nil {
+		g.showStatus("Nothing to undo", warningMessage, shortMessageDuration)
+		return
+	}
+	g.hint = nil
+	g.moveCursorTo(action)
+}
+
+// redo replays the last undone move and moves the cursor to the cell it changed
+func (g *Game) redo() {
+	action, err := g.logic.RedoMove()
+	if err != nil {
+		g.showStatus("Nothing to redo", warningMessage, shortMessageDuration)
+		return
+	}
+	g.hint = nil
+	g.moveCursorTo(action)
+}
+
+// moveCursorTo puts the cursor on the cell a move changed. Bulk pencil mark
+// changes span several cells and leave the cursor where it is.
+func (g *Game) moveCursorTo(action logic.Action) {
+	if action.Kind == logic.ClearMarksMove {
+		return
+	}
+	g.cursorX, g.cursorY = action.Col, action.Row
+}
+
 // CheckProgress will check the progress of the game
 func (g *Game) CheckProgress() {
 	if g.logic == nil {
@@ -410,65 +798,204 @@ func (g *Game) CheckProgress() {
 		)
 		g.showWinMessage = true
 		g.messageTimer = 180
+		g.recordCompletion()
 	}
 }
 
-// startGame will start a new game
-func (g *Game) startGame() {
-	// Load the puzzles from the file
-	puzzles, err := logic.LoadPuzzles("sample.txt")
-	if err != nil {
-		log.Fatalf("Error loading puzzles: %v", err)
+// ShowHint asks the logic package for the easiest deduction on the board,
+// highlights the cells involved and explains it in the status bar
+func (g *Game) ShowHint() {
+	if g.logic == nil {
+		return
 	}
 
-	randomPuzzle := logic.GetRandomPuzzle(puzzles)
-	logic.ShuffleAsh(&randomPuzzle)
-	// Remove numbers from the puzzle based on the difficulty level
-	switch g.difficulty {
-	case Easy:
-		logic.RemoveNumbersFromGrid(&randomPuzzle, 1)
-	case Medium:
-		logic.RemoveNumbersFromGrid(&randomPuzzle, 3)
-	case Hard:
-		logic.RemoveNumbersFromGrid(&randomPuzzle, 5)
+	// Every digit is a candidate except those earlier hints have ruled out
+	var candidates logic.Candidates
+	for i := 0; i < gridSize; i++ {
+		for j := 0; j < gridSize; j++ {
+			candidates[i][j] = ^g.hintEliminations[i][j]
+		}
 	}
 
-	// Set the puzzle to the game logic
-	g.logic = &logic.GameLogic{
-		Puzzle:    randomPuzzle,
-		MoveStack: []logic.Action{},
+	step, err := logic.NextStep(g.logic.Puzzle, candidates)
+	if errors.Is(err, logic.ErrWrongCandidates) {
+		// Earlier hints relied on a number that has since been undone
+		g.hintEliminations = [9][9]uint16{}
+		step, err = logic.NextStep(g.logic.Puzzle, logic.NewCandidates(g.logic.Puzzle))
 	}
 
-	// Clear all pencil marks when starting a new game
-	for i := 0; i < 9; i++ {
-		for j := 0; j < 9; j++ {
-			g.pencilMarks[i][j] = make(map[int]bool)
+	switch {
+	case errors.Is(err, logic.ErrNoStep):
+		g.hint = nil
+		g.showStatus("No hint available", warningMessage, normalMessageDuration)
+	case err != nil:
+		g.hint = nil
+		g.showStatus("There is a mistake on the board", errorMessage, normalMessageDuration)
+	default:
+		g.hint = step
+		g.recordStat(func(l *levelStats) { l.Hints++ })
+		// Remember the eliminations so the next hint moves on. The player's
+		// own pencil marks and undo history are left alone.
+		for _, e := range step.Eliminations {
+			g.hintEliminations[e.Row][e.Col] |= 1 << e.Value
 		}
+		g.showStatus(step.Explain(), infoMessage, longMessageDuration)
 	}
+}
 
-	// Reset win message state when starting a new game
+// startGame will start a new game from a fresh seed, taking a puzzle the
+// queue has ready or waiting for one without blocking the frame
+func (g *Game) startGame() {
+	key := queueKey{g.difficulty, g.removal}
+	if g.queue == nil {
+		g.generateGame(key, newSeed(), g.packPuzzles(), g.playGenerated)
+	} else if p, ok := g.queue.take(key); ok {
+		g.playGenerated(p)
+		return
+	} else {
+		g.waitForQueue(key)
+	}
+	g.showStatus(fmt.Sprintf("Generating a %v puzzle...", g.difficulty), infoMessage, longMessageDuration)
+}
+
+// beginGame starts playing the puzzle from a clean slate, counting it
+// towards the stats of the level it rates as and remembering its clue layout
+func (g *Game) beginGame(puzzle logic.Puzzle, rating logic.Rating, layout logic.RemovalStrategy) {
+	g.rating = rating
+	g.level, g.layout = ratingLevel(rating), layout
+
+	// Set the puzzle to the game logic, its filled cells become the givens
+	// and the pencil marks start out empty
+	g.logic = logic.NewGameLogic(puzzle)
+
+	// Reset win message and hint state when starting a new game
 	g.showWinMessage = false
 	g.messageTimer = 0
+	g.hint = nil
+	g.hintEliminations = [9][9]uint16{}
+	g.clock.Reset(0)
+	g.slotName = ""
+	g.completionRecorded = false
+	g.packKey, g.packPuzzleID = "", ""
+	g.seed = 0
+	g.daily = ""
+	g.cancelPending()
+	g.recordStat(func(l *levelStats) { l.Started++ })
 
 	g.state = Playing
 }
 
+// New puzzles have rotationally symmetric givens unless the player picks otherwise
+const defaultRemoval = logic.RotationalRemoval
+
+// cycleRemoval steps through the clue removal strategies, wrapping around
+func cycleRemoval(s logic.RemovalStrategy, step int) logic.RemovalStrategy {
+	n := len(logic.RemovalStrategies)
+	return logic.RemovalStrategies[((int(s)+step)%n+n)%n]
+}
+
+// changeRemoval picks another clue removal strategy and gets puzzles for it
+// generating straight away
+func (g *Game) changeRemoval(step int) {
+	g.removal = cycleRemoval(g.removal, step)
+	if g.queue != nil {
+		g.queue.selectKey(queueKey{g.difficulty, g.removal})
+	}
+}
+
+// clueRemoval returns how hard RemoveClues should try at a level.
+// Easy puzzles only need a few blanks, harder ones are pared down as far as
+// uniqueness allows and then sorted by the techniques they need.
+func clueRemoval(level DifficultyLevel) int {
+	if level == Easy {
+		return 1
+	}
+	return 5
+}
+
+// ratingLevel maps a grader rating onto the difficulty menu levels
+func ratingLevel(r logic.Rating) DifficultyLevel {
+	switch {
+	case r.Hardest <= logic.NakedSingle:
+		return Easy
+	case r.Hardest <= logic.HiddenTriple:
+		return Medium
+	default:
+		return Hard
+	}
+}
+
+// markStyle is how pencil marks are laid out in their cells. Centre marks
+// are kept apart and always drawn across the middle.
+type markStyle int
+
+const (
+	gridMarks   markStyle = iota // Each digit in its own spot of a 3x3 grid
+	cornerMarks                  // Digits in order round the edge, corners first
+)
+
+// String returns the display name of the pencil mark style
+func (s markStyle) String() string {
+	switch s {
+	case gridMarks:
+		return "Grid"
+	case cornerMarks:
+		return "Corner"
+	default:
+		return "Unknown"
+	}
+}
+
+// parseMarkStyle returns the pencil mark style with the given name
+func parseMarkStyle(name string) (markStyle, error) {
+	for s := gridMarks; s <= cornerMarks; s++ {
+		if strings.EqualFold(name, s.String()) {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown pencil mark style %q", name)
+}
+
+// String returns the display name of the difficulty level
+func (d DifficultyLevel) String() string {
+	switch d {
+	case Easy:
+		return "Easy"
+	case Medium:
+		return "Medium"
+	case Hard:
+		return "Hard"
+	default:
+		return "Unknown"
+	}
+}
+
+// parseDifficulty returns the level with the given name
+func parseDifficulty(name string) (DifficultyLevel, error) {
+	for level := Easy; level <= Hard; level++ {
+		if strings.EqualFold(name, level.String()) {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown difficulty %q", name)
+}
+
 // Lets check if the entered number is valid as per Sudoku rules.
 func (g *Game) isNumValid(row, col, num int) bool {
-	if g.logic == nil {
+	if g.logic == nil || num < 1 || num > 9 {
 		return false
 	}
 
-	// Check row
+	// Check row, skipping the cell itself so player entries can be overwritten
 	for i := 0; i < gridSize; i++ {
-		if g.logic.Puzzle[row][i] == num {
+		if i != col && g.logic.Puzzle[row][i] == num {
 			return false
 		}
 	}
 
 	// Check column
 	for i := 0; i < gridSize; i++ {
-		if g.logic.Puzzle[i][col] == num {
+		if i != row && g.logic.Puzzle[i][col] == num {
 			return false
 		}
 	}
@@ -478,7 +1005,7 @@ func (g *Game) isNumValid(row, col, num int) bool {
 	subGridColStart := (col / 3) * 3
 	for r := subGridRowStart; r < subGridRowStart+3; r++ {
 		for c := subGridColStart; c < subGridColStart+3; c++ {
-			if g.logic.Puzzle[r][c] == num {
+			if (r != row || c != col) && g.logic.Puzzle[r][c] == num {
 				return false
 			}
 		}
@@ -500,16 +1027,30 @@ func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
 }
 
 func main() {
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
+	opts, err := parseFlags(os.Args[1:])
+	if err != nil {
+		os.Exit(2) // The flag package has already printed the problem
+	}
 
-	// Create a new game instance
-	game := NewGame()
+	// Create a new game instance, going straight into the game for --seed
+	game := NewGame(opts)
+	if opts.HasSeed {
+		game.startSeededGame(opts.Seed)
+	}
 	// Run the game (this will open a window and start rendering)
 	ebiten.SetWindowSize(screenWidth, screenHeight)
 	ebiten.SetWindowTitle("Sudoku BY Ash!")
+	ebiten.SetWindowClosingHandled(true) // Save before the window goes away
 
 	//ebiten.SetWindowResizable(true)
 
-	if err := ebiten.RunGame(game); err != nil {
+	err = ebiten.RunGame(game)
+	game.queue.close()
+	if err != nil {
 		if err == ebiten.Termination {
 			// Clean Exit
 			os.Exit(0)
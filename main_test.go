@@ -1,7 +1,14 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/afroash/mygame/logic"
 )
@@ -25,14 +32,27 @@ func setupTestGame(t *testing.T) *Game {
 	}
 
 	puzzle := puzzles[0] // Use first puzzle for consistent testing
-	game.logic = &logic.GameLogic{
-		Puzzle:    puzzle,
-		MoveStack: []logic.Action{},
-	}
+	game.logic = logic.NewGameLogic(puzzle)
 
 	return game
 }
 
+// waitForGame runs checkQueue until the puzzle being generated is playing
+func waitForGame(t *testing.T, game *Game) {
+	t.Helper()
+	deadline := time.Now().Add(time.Minute)
+	for game.pending != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("puzzle wasn't generated in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+		game.checkQueue()
+	}
+	if game.state != Playing {
+		t.Fatalf("state after generating = %v; want Playing", game.state)
+	}
+}
+
 // Test number validation
 func TestIsNumValid(t *testing.T) {
 	tests := []struct {
@@ -84,9 +104,7 @@ func TestGameStateTransitions(t *testing.T) {
 	game.selected = int(Easy)
 	game.difficulty = Easy
 	game.startGame()
-	if game.state != Playing {
-		t.Errorf("State after starting game = %v; want Playing", game.state)
-	}
+	waitForGame(t, game)
 }
 
 // Test status message system
@@ -163,67 +181,94 @@ func TestUndoMove(t *testing.T) {
 	game.logic.AddMove(0, 0, oldValue, newValue)
 
 	// Test undo
-	game.logic.UndoMove()
+	action, err := game.logic.UndoMove()
+	if err != nil {
+		t.Fatalf("UndoMove returned error: %v", err)
+	}
 	if game.logic.Puzzle[0][0] != oldValue {
 		t.Errorf("After undo, value = %v; want %v", game.logic.Puzzle[0][0], oldValue)
 	}
+	if action.Row != 0 || action.Col != 0 || action.NewValue != newValue {
+		t.Errorf("UndoMove returned %+v; want the move at 0,0 to %d", action, newValue)
+	}
 
 	// Test undo with empty stack
-	game.logic.UndoMove() // Should not panic
+	if _, err := game.logic.UndoMove(); err != logic.ErrNothingToUndo {
+		t.Errorf("UndoMove on empty stack = %v; want ErrNothingToUndo", err)
+	}
 }
 
-// Test puzzle difficulty settings
-func TestDifficultySettings(t *testing.T) {
-	difficulties := []struct {
-		level         DifficultyLevel
-		expectedEmpty int
-	}{
-		{Easy, 30},   // 20 + 1*10
-		{Medium, 50}, // 20 + 3*10
-		{Hard, 70},   // 20 + 5*10
+// Test redo functionality
+func TestRedoMove(t *testing.T) {
+	game := setupTestGame(t)
+	oldValue := game.logic.Puzzle[0][0]
+
+	game.logic.AddMove(0, 0, oldValue, 5)
+	game.logic.AddMove(0, 1, game.logic.Puzzle[0][1], 6)
+	game.logic.UndoMove()
+	game.logic.UndoMove()
+
+	// Redo replays the moves in the order they were made
+	action, err := game.logic.RedoMove()
+	if err != nil || action.Col != 0 || game.logic.Puzzle[0][0] != 5 {
+		t.Errorf("first redo = %+v, %v; want the move at 0,0", action, err)
+	}
+	action, err = game.logic.RedoMove()
+	if err != nil || action.Col != 1 || game.logic.Puzzle[0][1] != 6 {
+		t.Errorf("second redo = %+v, %v; want the move at 0,1", action, err)
+	}
+	if _, err := game.logic.RedoMove(); err != logic.ErrNothingToRedo {
+		t.Errorf("redo past the end = %v; want ErrNothingToRedo", err)
 	}
 
-	for _, diff := range difficulties {
-		t.Run(diff.level.String(), func(t *testing.T) {
+	// A new move drops anything left to redo
+	game.logic.UndoMove()
+	game.logic.AddMove(0, 2, game.logic.Puzzle[0][2], 7)
+	if _, err := game.logic.RedoMove(); err != logic.ErrNothingToRedo {
+		t.Errorf("redo after a new move = %v; want ErrNothingToRedo", err)
+	}
+	if len(game.logic.MoveStack) != 2 {
+		t.Errorf("move stack has %d moves; want 2", len(game.logic.MoveStack))
+	}
+}
+
+// Test puzzle difficulty settings
+func TestDifficultySettings(t *testing.T) {
+	for _, level := range []DifficultyLevel{Easy, Medium, Hard} {
+		t.Run(level.String(), func(t *testing.T) {
 			game := setupTestGame(t)
-			game.difficulty = diff.level
+			game.difficulty = level
 			game.startGame()
+			waitForGame(t, game)
 
-			emptyCount := 0
-			for i := 0; i < gridSize; i++ {
-				for j := 0; j < gridSize; j++ {
-					if game.logic.Puzzle[i][j] == 0 {
-						emptyCount++
-					}
-				}
+			if !logic.HasUniqueSolution(game.logic.Puzzle) {
+				t.Errorf("Difficulty %v: puzzle does not have a unique solution", level)
 			}
 
-			// Allow some variance due to random removal
-			if emptyCount < diff.expectedEmpty-5 || emptyCount > diff.expectedEmpty+5 {
-				t.Errorf("Difficulty %v: empty cells = %v; want approximately %v",
-					diff.level, emptyCount, diff.expectedEmpty)
+			rating := logic.Grade(game.logic.Puzzle)
+			if got := ratingLevel(rating); got != level {
+				t.Errorf("Difficulty %v: puzzle needs %v, which rates as %v", level, rating.Hardest, got)
+			}
+			if game.rating != rating {
+				t.Errorf("Difficulty %v: game rating = %+v; want %+v", level, game.rating, rating)
 			}
 		})
 	}
 }
 
-// Helper method to convert DifficultyLevel to string
-func (d DifficultyLevel) String() string {
-	switch d {
-	case Easy:
-		return "Easy"
-	case Medium:
-		return "Medium"
-	case Hard:
-		return "Hard"
-	default:
-		return "Unknown"
-	}
-}
-
 // Test game initialization
 func TestGameInitialization(t *testing.T) {
-	game := NewGame()
+	// Keep the player's own config, saves and stats out of the test
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("AppData", filepath.Join(home, "AppData"))
+	if dir, err := configDir(); err != nil || !strings.HasPrefix(dir, home) {
+		t.Fatalf("configDir() = %q, %v; want a directory under %q", dir, err, home)
+	}
+
+	game := NewGame(options{})
+	defer game.queue.close()
 
 	if game.state != MainMenu {
 		t.Error("New game should start in MainMenu state")
@@ -238,3 +283,999 @@ func TestGameInitialization(t *testing.T) {
 		t.Error("New game should not be in exit state")
 	}
 }
+
+// Test the hint key
+func TestShowHint(t *testing.T) {
+	game := setupTestGame(t)
+	game.state = Playing
+
+	answer := game.logic.Puzzle[4][4]
+	game.logic.Puzzle[4][4] = 0
+
+	game.ShowHint()
+	if game.hint == nil {
+		t.Fatal("ShowHint should set a hint")
+	}
+	if game.hint.Cell.Row != 4 || game.hint.Cell.Col != 4 || game.hint.Value != answer {
+		t.Errorf("hint = %v at %v; want %d at R5C5", game.hint.Value, game.hint.Cell, answer)
+	}
+	if !game.statusMessage.isVisible || game.statusMessage.color != infoMessage {
+		t.Error("ShowHint should explain the hint in the status bar")
+	}
+
+	// Asking for a hint is not a move: marks and undo history stay as they were
+	game.logic.TogglePencilMark(4, 4, answer%9+1)
+	game.logic.TogglePencilMark(4, 4, answer)
+	game.logic.UndoMove()
+	marks, moves, redo := game.logic.Marks, len(game.logic.MoveStack), len(game.logic.RedoStack)
+	game.ShowHint()
+	if game.logic.Marks != marks || len(game.logic.MoveStack) != moves || len(game.logic.RedoStack) != redo {
+		t.Errorf("ShowHint changed the game: %d moves, %d to redo; want %d and %d",
+			len(game.logic.MoveStack), len(game.logic.RedoStack), moves, redo)
+	}
+
+	// A wrong number leaves nothing to hint towards
+	game.logic.Puzzle[4][4] = answer%9 + 1
+	game.logic.Puzzle[0][0] = 0
+	game.ShowHint()
+	if game.hint != nil || game.statusMessage.color != errorMessage {
+		t.Error("ShowHint on a broken board should report a mistake")
+	}
+}
+
+// Test that givens are protected while player entries can be changed
+func TestGivensAndEntries(t *testing.T) {
+	game := setupTestGame(t)
+	answer := game.logic.Puzzle[0][0]
+
+	// A clue can't be overwritten or erased
+	if err := game.logic.SetCell(0, 0, 0); err != logic.ErrGivenCell {
+		t.Errorf("SetCell on a given = %v; want ErrGivenCell", err)
+	}
+
+	// Player entries can be overwritten and erased
+	game.logic = logic.NewGameLogic(game.logic.Puzzle)
+	game.logic.Puzzle[0][0] = 0
+	game.logic.Givens[0][0] = false
+	game.cursorX, game.cursorY = 0, 0
+
+	game.enterNumber(answer)
+	if game.logic.Puzzle[0][0] != answer {
+		t.Fatalf("after entering, cell = %d; want %d", game.logic.Puzzle[0][0], answer)
+	}
+	game.eraseCell()
+	if game.logic.Puzzle[0][0] != 0 {
+		t.Errorf("after erasing, cell = %d; want 0", game.logic.Puzzle[0][0])
+	}
+	if len(game.logic.MoveStack) != 2 {
+		t.Errorf("move stack has %d moves; want 2", len(game.logic.MoveStack))
+	}
+	if game.logic.IsGiven(0, 0) {
+		t.Error("player entry should not become a given")
+	}
+}
+
+// Test that pencil marks are undone and redone along with numbers
+func TestPencilMarkUndo(t *testing.T) {
+	game := setupTestGame(t)
+	answer := game.logic.Puzzle[0][0]
+	game.logic.Puzzle[0][0] = 0
+	game.logic.Givens[0][0] = false
+	game.cursorX, game.cursorY = 0, 0
+
+	game.togglePencilMark(answer)
+	game.togglePencilMark(answer%9 + 1)
+	marks := game.logic.Marks[0][0]
+	if !game.logic.HasPencilMark(0, 0, answer) {
+		t.Fatal("pencil mark not set")
+	}
+
+	// Entering a number clears the marks, undo brings them back
+	game.enterNumber(answer)
+	if game.logic.Marks[0][0] != 0 {
+		t.Error("entering a number should clear the cell's pencil marks")
+	}
+	game.undo()
+	if game.logic.Puzzle[0][0] != 0 || game.logic.Marks[0][0] != marks {
+		t.Errorf("after undo, cell = %d with marks %b; want 0 with %b",
+			game.logic.Puzzle[0][0], game.logic.Marks[0][0], marks)
+	}
+
+	// Undo the second toggle, then redo it
+	game.undo()
+	if game.logic.HasPencilMark(0, 0, answer%9+1) {
+		t.Error("undo should remove the last pencil mark")
+	}
+	game.redo()
+	if game.logic.Marks[0][0] != marks {
+		t.Errorf("after redo, marks = %b; want %b", game.logic.Marks[0][0], marks)
+	}
+
+	// Clearing every mark is a single move
+	game.logic.TogglePencilMark(4, 4, 5)
+	if !game.logic.ClearPencilMarks() {
+		t.Fatal("ClearPencilMarks found nothing to clear")
+	}
+	if game.logic.Marks[0][0] != 0 || game.logic.Marks[4][4] != 0 {
+		t.Error("ClearPencilMarks left marks behind")
+	}
+	game.undo()
+	if game.logic.Marks[0][0] != marks || !game.logic.HasPencilMark(4, 4, 5) {
+		t.Error("undoing a bulk clear should restore every cell")
+	}
+}
+
+// Test undoing a single cell without losing moves elsewhere
+func TestUndoCell(t *testing.T) {
+	game := setupTestGame(t)
+	game.logic = logic.NewGameLogic(logic.Puzzle{}) // Nothing for the moves to clash with
+
+	game.logic.SetCell(0, 0, 5)
+	game.logic.SetCell(0, 1, 6)
+	game.logic.SetCell(0, 0, 7)
+	game.logic.TogglePencilMark(4, 4, 3)
+
+	// Only the latest change to R1C1 goes, later moves stay
+	action, err := game.logic.UndoCell(0, 0)
+	if err != nil || action.NewValue != 7 {
+		t.Fatalf("UndoCell = %+v, %v; want the change to 7", action, err)
+	}
+	if game.logic.Puzzle[0][0] != 5 || game.logic.Puzzle[0][1] != 6 || !game.logic.HasPencilMark(4, 4, 3) {
+		t.Errorf("UndoCell touched other cells: row 0 = %v", game.logic.Puzzle[0])
+	}
+	if len(game.logic.MoveStack) != 3 {
+		t.Errorf("move stack has %d moves; want 3", len(game.logic.MoveStack))
+	}
+
+	// Global undo carries on from the remaining history
+	game.logic.UndoMove()
+	game.logic.UndoMove()
+	game.logic.UndoMove()
+	if game.logic.Puzzle[0][0] != 0 || game.logic.Puzzle[0][1] != 0 || game.logic.Marks[4][4] != 0 {
+		t.Errorf("after undoing everything, row 0 = %v", game.logic.Puzzle[0])
+	}
+
+	if _, err := game.logic.UndoCell(0, 0); err != logic.ErrNothingToUndo {
+		t.Errorf("UndoCell on untouched cell = %v; want ErrNothingToUndo", err)
+	}
+
+	// A bulk clear only gives back the marks of the chosen cell
+	game.logic.TogglePencilMark(4, 4, 3)
+	game.logic.TogglePencilMark(5, 5, 2)
+	game.logic.ClearPencilMarks()
+	game.logic.UndoCell(4, 4)
+	if !game.logic.HasPencilMark(4, 4, 3) || game.logic.HasPencilMark(5, 5, 2) {
+		t.Error("UndoCell on a bulk clear should restore only that cell")
+	}
+	game.logic.UndoMove() // The rest of the bulk clear
+	if !game.logic.HasPencilMark(5, 5, 2) {
+		t.Error("undoing the rest of the bulk clear should restore the other cell")
+	}
+
+	// A number that has since been placed elsewhere in the row isn't brought back
+	game.logic.SetCell(0, 0, 5)
+	game.logic.SetCell(0, 0, 7)
+	game.logic.SetCell(0, 3, 5)
+	moves := len(game.logic.MoveStack)
+	if _, err := game.logic.UndoCell(0, 0); err != logic.ErrUndoConflict {
+		t.Errorf("UndoCell bringing back a clashing 5 = %v; want ErrUndoConflict", err)
+	}
+	if game.logic.Puzzle[0][0] != 7 || len(game.logic.MoveStack) != moves {
+		t.Errorf("refused UndoCell changed the game: R1C1 = %d, %d moves", game.logic.Puzzle[0][0], len(game.logic.MoveStack))
+	}
+
+	// Undoing an auto cleared number leaves the peers' marks as they are now
+	game.logic = logic.NewGameLogic(logic.Puzzle{})
+	game.logic.TogglePencilMark(0, 1, 5)
+	game.logic.SetCellAndEliminate(0, 0, 5)
+	game.logic.TogglePencilMark(0, 1, 3)
+	game.logic.UndoCell(0, 0)
+	if game.logic.Puzzle[0][0] != 0 || game.logic.Marks[0][1] != 1<<3 {
+		t.Errorf("after UndoCell, R1C1 = %d and R1C2 marks = %09b; want empty and only 3",
+			game.logic.Puzzle[0][0], game.logic.Marks[0][1]>>1)
+	}
+	game.logic.UndoMove() // Pencilling in 3
+	if game.logic.Marks[0][1] != 0 {
+		t.Errorf("after undoing the 3, R1C2 marks = %09b; want none", game.logic.Marks[0][1]>>1)
+	}
+	game.logic.UndoMove() // Clearing 5 from the peer
+	if game.logic.Marks[0][1] != 1<<5 || game.logic.Puzzle[0][0] != 0 {
+		t.Errorf("after undoing the elimination, R1C2 marks = %09b; want only 5", game.logic.Marks[0][1]>>1)
+	}
+}
+
+// Test saving a game and continuing it later
+func TestSaveAndContinue(t *testing.T) {
+	game := setupTestGame(t)
+	game.savePath = filepath.Join(t.TempDir(), "autosave.json")
+	game.level = Hard
+	game.clock.Reset(90 * time.Second)
+	game.state = Playing
+
+	game.logic.Puzzle[0][0] = 0
+	game.logic.Givens[0][0] = false
+	game.logic.TogglePencilMark(0, 0, 3)
+	game.logic.SetCell(0, 0, 4)
+	want := *game.logic
+
+	if err := game.autosave(); err != nil {
+		t.Fatalf("autosave returned error: %v", err)
+	}
+	if !game.canContinue {
+		t.Error("a saved game should be offered to continue")
+	}
+
+	// A fresh game picks the save up from disk
+	resumed := setupTestGame(t)
+	resumed.logic = nil
+	resumed.savePath = game.savePath
+	resumed.canContinue = true
+	resumed.continueGame()
+
+	if resumed.state != Playing || resumed.logic == nil {
+		t.Fatalf("continueGame left state %v; want Playing", resumed.state)
+	}
+	if !reflect.DeepEqual(*resumed.logic, want) {
+		t.Errorf("resumed game = %+v; want %+v", *resumed.logic, want)
+	}
+	if resumed.level != Hard || resumed.clock.Elapsed() != 90*time.Second {
+		t.Errorf("resumed level %v, elapsed %v; want Hard, 1m30s", resumed.level, resumed.clock.Elapsed())
+	}
+
+	// Undo history survives the round trip
+	if _, err := resumed.logic.UndoMove(); err != nil || resumed.logic.Marks[0][0] == 0 {
+		t.Errorf("undo after resume = %v; want the pencil mark back", err)
+	}
+}
+
+// Test that save files from another version are refused
+func TestReadSaveVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "autosave.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readSave(path); err == nil {
+		t.Error("readSave should reject an unknown version")
+	}
+}
+
+// Test creating, listing, renaming and deleting save slots
+func TestSaveSlots(t *testing.T) {
+	game := setupTestGame(t)
+	game.slots = newSlotManager(t.TempDir())
+	game.level = Medium
+
+	// Blank two cells and fill one back in: 50% of the open cells are done
+	for _, col := range []int{0, 1} {
+		game.logic.Puzzle[0][col] = 0
+		game.logic.Givens[0][col] = false
+	}
+	game.logic.SetCell(0, 0, 5)
+
+	game.slotName = "Lunch board"
+	game.saveToSlot()
+	if game.statusMessage.color != successMessage {
+		t.Fatalf("saveToSlot failed: %s", game.statusMessage.text)
+	}
+
+	slots, err := game.slots.List()
+	if err != nil || len(slots) != 1 {
+		t.Fatalf("List() = %v, %v; want one slot", slots, err)
+	}
+	if slots[0].Name != "Lunch board" || slots[0].Difficulty != Medium || slots[0].Progress != 50 {
+		t.Errorf("slot = %+v; want Lunch board, Medium, 50%%", slots[0])
+	}
+
+	if err := game.slots.Rename("Lunch board", "Daily"); err != nil {
+		t.Fatalf("Rename returned error: %v", err)
+	}
+	s, err := game.slots.Load("Daily")
+	if err != nil || s.Name != "Daily" || s.Game.Puzzle[0][0] != 5 {
+		t.Errorf("Load(renamed) = %+v, %v; want the saved game under the new name", s, err)
+	}
+	if _, err := game.slots.Load("Lunch board"); err == nil {
+		t.Error("the old slot name should be gone after a rename")
+	}
+
+	// Names that share a file name don't overwrite each other
+	if err := game.slots.Save("Lunch!", game.snapshot()); err != nil {
+		t.Fatalf("Save returned error: %v", err)
+	}
+	if err := game.slots.Save("lunch?", game.snapshot()); err == nil {
+		t.Error("saving lunch? should be refused while Lunch! uses its file")
+	}
+	if err := game.slots.Rename("Daily", "LUNCH"); err == nil {
+		t.Error("renaming to LUNCH should be refused while Lunch! uses its file")
+	}
+	if err := game.slots.Delete("lunch?"); err == nil {
+		t.Error("deleting lunch? should leave Lunch! alone")
+	}
+	if s, err := game.slots.Load("Lunch!"); err != nil || s.Name != "Lunch!" {
+		t.Errorf("Load(Lunch!) = %+v, %v; want the slot left as it was", s, err)
+	}
+	if err := game.slots.Save("Lunch!", game.snapshot()); err != nil {
+		t.Errorf("saving over the same slot returned error: %v", err)
+	}
+	if err := game.slots.Delete("Lunch!"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+
+	if err := game.slots.Delete("Daily"); err != nil {
+		t.Fatalf("Delete returned error: %v", err)
+	}
+	if slots, _ := game.slots.List(); len(slots) != 0 {
+		t.Errorf("List() after delete = %v; want none", slots)
+	}
+
+	if _, err := slotFileName("  ?! "); err == nil {
+		t.Error("slotFileName should reject names without letters or digits")
+	}
+}
+
+// Test that the game clock only counts while running
+func TestGameClock(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := gameClock{now: func() time.Time { return now }}
+
+	clock.Start()
+	now = now.Add(30 * time.Second)
+	if got := clock.Elapsed(); got != 30*time.Second {
+		t.Errorf("Elapsed while running = %v; want 30s", got)
+	}
+
+	// Time spent stopped is not counted
+	clock.Stop()
+	now = now.Add(time.Hour)
+	if got := clock.Elapsed(); got != 30*time.Second {
+		t.Errorf("Elapsed while stopped = %v; want 30s", got)
+	}
+
+	clock.Start()
+	clock.Start() // Starting twice must not reset the run
+	now = now.Add(15 * time.Second)
+	if got := clock.Elapsed(); got != 45*time.Second {
+		t.Errorf("Elapsed after resuming = %v; want 45s", got)
+	}
+
+	clock.Reset(time.Minute)
+	if clock.Running() || clock.Elapsed() != time.Minute {
+		t.Errorf("after Reset, running = %v, elapsed = %v; want stopped at 1m", clock.Running(), clock.Elapsed())
+	}
+
+	if got := formatDuration(3*time.Hour + 4*time.Minute + 5*time.Second); got != "3:04:05" {
+		t.Errorf("formatDuration = %q; want 3:04:05", got)
+	}
+	if got := formatDuration(65 * time.Second); got != "1:05" {
+		t.Errorf("formatDuration = %q; want 1:05", got)
+	}
+}
+
+// Test pausing hides the game and stops the clock
+func TestPause(t *testing.T) {
+	game := setupTestGame(t)
+	game.state = Playing
+	game.clock.Start()
+
+	game.pause()
+	if game.state != Paused || game.clock.Running() {
+		t.Errorf("after pause, state = %v, running = %v; want Paused and stopped", game.state, game.clock.Running())
+	}
+}
+
+// Test statistics are counted per difficulty and survive a reload
+func TestStatistics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	stats, err := loadStats(path)
+	if err != nil {
+		t.Fatalf("loadStats on a missing file returned error: %v", err)
+	}
+
+	game := setupTestGame(t)
+	game.stats = stats
+	game.level = Medium
+	game.state = Playing
+	game.recordStat(func(l *levelStats) { l.Started++ })
+
+	answer := game.logic.Puzzle[0][0]
+	game.logic.Puzzle[0][0] = 0
+	game.logic.Givens[0][0] = false
+	game.cursorX, game.cursorY = 0, 0
+	game.clock.Reset(2 * time.Minute)
+
+	game.enterNumber(game.logic.Puzzle[0][1]) // Clashes with its row
+	game.enterNumber(answer)
+	game.recordCompletion() // Counted only once
+
+	loaded, err := loadStats(path)
+	if err != nil {
+		t.Fatalf("loadStats returned error: %v", err)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary stats file left behind: %v", err)
+	}
+	want := levelStats{
+		Started:   1,
+		Completed: 1,
+		BestTime:  2 * time.Minute,
+		TotalTime: 2 * time.Minute,
+		Mistakes:  1,
+	}
+	if got := *loaded.level(Medium); got != want {
+		t.Errorf("Medium stats = %+v; want %+v", got, want)
+	}
+	if got := *loaded.level(Easy); got != (levelStats{}) {
+		t.Errorf("Easy stats = %+v; want none", got)
+	}
+	if avg := want.AverageTime(); avg != 2*time.Minute {
+		t.Errorf("AverageTime() = %v; want 2m0s", avg)
+	}
+}
+
+// Test loading puzzle packs from flags, directories and the embedded default
+func TestPuzzlePacks(t *testing.T) {
+	opts, err := parseFlags([]string{"-pack", "a.txt", "-default-pack", "-pack", "packs"})
+	if err != nil {
+		t.Fatalf("parseFlags returned error: %v", err)
+	}
+	if !opts.DefaultPack || !reflect.DeepEqual(opts.Packs, []string{"a.txt", "packs"}) {
+		t.Errorf("parseFlags = %+v; want the default pack and two extra packs", opts)
+	}
+
+	def, err := loadDefaultPack()
+	if err != nil || len(def.Puzzles) != 4 {
+		t.Fatalf("loadDefaultPack = %d puzzles, %v; want the 4 embedded puzzles", len(def.Puzzles), err)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "good.txt"), defaultPackData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("not a puzzle\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	packs, errs := loadPacks([]string{dir, filepath.Join(dir, "missing.txt")})
+	if len(packs) != 1 || packs[0].Title != "good" || len(errs) != 2 {
+		t.Errorf("loadPacks = %d packs, %v; want good.txt and two errors", len(packs), errs)
+	}
+
+	// A broken pack is reported instead of stopping the game
+	game := setupTestGame(t)
+	game.loadPuzzleSources(options{DefaultPack: true, Packs: []string{filepath.Join(dir, "bad.txt")}})
+	if len(game.packs) != 1 || !game.statusMessage.isVisible || game.statusMessage.color != errorMessage {
+		t.Errorf("loadPuzzleSources kept %d packs, status %+v; want the default pack and an error", len(game.packs), game.statusMessage)
+	}
+	game.startGame()
+	waitForGame(t, game)
+}
+
+// Test playing through a pack in order with progress kept per puzzle
+func TestPackBrowser(t *testing.T) {
+	dir := t.TempDir()
+	packFile := filepath.Join(dir, "starter.json")
+	pack := `{
+  "title": "Starter",
+  "author": "Ash",
+  "puzzles": [
+    {"id": "one", "puzzle": "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79", "difficulty": "gentle"},
+    {"id": "two", "puzzle": "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..7."}
+  ]
+}`
+	if err := os.WriteFile(packFile, []byte(pack), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	game := setupTestGame(t)
+	game.loadPuzzleSources(options{Packs: []string{packFile}})
+	progress, err := loadProgress(filepath.Join(dir, "progress.json"))
+	if err != nil {
+		t.Fatalf("loadProgress returned error: %v", err)
+	}
+	game.progress = progress
+	if len(game.packs) != 2 || game.packs[0].Title != defaultPackName || game.packs[1].Title != "Starter" {
+		t.Fatalf("loaded packs %+v; want the default pack then Starter", game.packs)
+	}
+	if n := len(game.packPuzzles()); n != 2 {
+		t.Errorf("New Game draws from %d puzzles; want Starter's 2 only", n)
+	}
+
+	starter := &game.packs[1]
+	game.difficulty = Hard
+	game.playPackPuzzle(starter, progress.nextPuzzle(starter))
+	if game.packPuzzleID != "one" || game.logic.Puzzle != starter.Puzzles[0].Puzzle {
+		t.Fatalf("playing Starter gave puzzle %q; want the first one as written", game.packPuzzleID)
+	}
+	if game.difficulty != Hard || game.level != Easy || game.layout != logic.UnknownRemoval {
+		t.Errorf("after a pack puzzle, difficulty = %v, level = %v and layout = %v; "+
+			"want Hard kept and the puzzle counted as Easy with an unknown layout",
+			game.difficulty, game.level, game.layout)
+	}
+	if s := game.snapshot(); s.Pack != starter.Key || s.PuzzleID != "one" {
+		t.Errorf("snapshot pack = %q #%q; want the Starter pack and puzzle one", s.Pack, s.PuzzleID)
+	}
+
+	game.clock.Reset(time.Minute)
+	game.recordCompletion()
+	reloaded, err := loadProgress(progress.path)
+	if err != nil {
+		t.Fatalf("loadProgress returned error: %v", err)
+	}
+	if _, err := os.Stat(progress.path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary progress file left behind: %v", err)
+	}
+	if !reloaded.solved(starter.Key, "one") || reloaded.solvedCount(starter) != 1 {
+		t.Errorf("progress after solving = %+v; want puzzle one solved", reloaded.Packs)
+	}
+	if next := reloaded.nextPuzzle(starter); next != 1 {
+		t.Errorf("nextPuzzle = %d; want the second puzzle", next)
+	}
+
+	// Finished grids in the default pack get clues removed before playing
+	game.playPackPuzzle(&game.packs[0], 0)
+	if isFull(game.logic.Puzzle) {
+		t.Error("the default pack's grids should be played with clues removed")
+	}
+}
+
+// Test the dedupe command drops disguised copies and keeps the rest
+func TestDedupeCommand(t *testing.T) {
+	puzzle := "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	p, err := logic.ParsePuzzle(puzzle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated := logic.Rotate(1).Apply(p)
+	other := p
+	other[8][8] = 0
+
+	input := puzzle + "\n" + other.String() + "\n" + rotated.String() + "\n"
+	var stdout, stderr strings.Builder
+	if err := runDedupe(nil, strings.NewReader(input), &stdout, &stderr); err != nil {
+		t.Fatalf("runDedupe returned error: %v", err)
+	}
+	want := puzzle + "\n" + other.String() + "\n"
+	if stdout.String() != want {
+		t.Errorf("dedupe output = %q; want %q", stdout.String(), want)
+	}
+	if !strings.Contains(stderr.String(), "puzzle 3 duplicates puzzle 1") {
+		t.Errorf("dedupe report = %q; want puzzle 3 reported", stderr.String())
+	}
+}
+
+// Test games made from the same seed are the same game
+func TestSeededGame(t *testing.T) {
+	first := setupTestGame(t)
+	first.difficulty = Easy
+	first.startSeededGame(424242)
+	waitForGame(t, first)
+
+	second := setupTestGame(t)
+	second.difficulty = Easy
+	second.startSeededGame(424242)
+	waitForGame(t, second)
+
+	if first.logic.Puzzle != second.logic.Puzzle {
+		t.Errorf("seed 424242 gave %v then %v; want the same puzzle", first.logic.Puzzle, second.logic.Puzzle)
+	}
+	if first.seed != 424242 || first.snapshot().Seed != 424242 {
+		t.Errorf("game seed = %d, saved seed = %d; want 424242", first.seed, first.snapshot().Seed)
+	}
+	if first.level != Easy || first.layout != first.removal {
+		t.Errorf("seeded game is %v with %v clues; want Easy with %v", first.level, first.layout, first.removal)
+	}
+
+	// A puzzle the generator settled for counts at the level it rates as
+	easy, err := logic.ParsePuzzle("53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79")
+	if err != nil {
+		t.Fatalf("ParsePuzzle returned error: %v", err)
+	}
+	rating := logic.Grade(easy)
+	first.playGenerated(queuedPuzzle{key: queueKey{Hard, logic.MirrorRemoval}, seed: 9, puzzle: easy, rating: rating})
+	if first.level != ratingLevel(rating) || first.level == Hard || first.statusMessage.color != warningMessage {
+		t.Errorf("settled puzzle counts as %v with status %q; want %v and a warning",
+			first.level, first.statusMessage.text, ratingLevel(rating))
+	}
+
+	opts, err := parseFlags([]string{"--seed", "424242"})
+	if err != nil || !opts.HasSeed || opts.Seed != 424242 {
+		t.Errorf("parseFlags(--seed 424242) = %+v, %v; want the seed set", opts, err)
+	}
+	if _, err := parseFlags([]string{"--seed", "0"}); err == nil {
+		t.Error("parseFlags(--seed 0) should fail")
+	}
+	opts, err = parseFlags([]string{"--seed", "7", "--difficulty", "hard", "--symmetry", "mirror"})
+	if err != nil || opts.Difficulty != "hard" || opts.Symmetry != "mirror" {
+		t.Errorf("parseFlags(--difficulty hard --symmetry mirror) = %+v, %v; want both set", opts, err)
+	}
+	for _, args := range [][]string{{"--difficulty", "expert"}, {"--symmetry", "spiral"}} {
+		if _, err := parseFlags(args); err == nil {
+			t.Errorf("parseFlags(%v) should fail", args)
+		}
+	}
+	if _, err := parseSeed("12a"); err == nil {
+		t.Error("parseSeed(12a) should fail")
+	}
+}
+
+func TestDailyPuzzle(t *testing.T) {
+	day := time.Date(2024, time.January, 31, 22, 0, 0, 0, time.Local)
+	if seed := dailySeed(day); seed != 20240131 {
+		t.Errorf("dailySeed(%v) = %d; want 20240131", day, seed)
+	}
+
+	// The daily ignores the selected difficulty and any configured packs
+	first := setupTestGame(t)
+	first.difficulty = Easy
+	first.removal = logic.MirrorRemoval
+	first.startDaily(day)
+	waitForGame(t, first)
+	second := setupTestGame(t)
+	second.difficulty = Hard
+	second.useDefaultPack = true
+	second.startDaily(day.Add(time.Hour))
+	waitForGame(t, second)
+
+	if first.logic.Puzzle != second.logic.Puzzle {
+		t.Errorf("daily puzzles for the same day differ: %v and %v", first.logic.Puzzle, second.logic.Puzzle)
+	}
+	if first.level != dailyDifficulty || first.layout != dailyRemoval || first.daily != "2024-01-31" ||
+		first.snapshot().Daily != "2024-01-31" {
+		t.Errorf("daily game is %v with %v clues, date %q; want %v with %v clues on 2024-01-31",
+			first.level, first.layout, first.daily, dailyDifficulty, dailyRemoval)
+	}
+	if first.difficulty != Easy || second.difficulty != Hard || first.removal != logic.MirrorRemoval {
+		t.Errorf("daily changed the settings for new games to %v, %v", first.difficulty, first.removal)
+	}
+
+	stats, err := loadStats(filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("loadStats returned error: %v", err)
+	}
+	first.stats = stats
+	first.recordCompletion()
+	first.recordDaily() // Counted only once
+	if !stats.Daily.doneOn("2024-01-31") || stats.Daily.Streak != 1 || stats.Daily.Completed != 1 {
+		t.Errorf("after one daily, stats = %+v; want today's done and a streak of 1", stats.Daily)
+	}
+
+	tests := []struct {
+		date   string
+		streak int
+	}{
+		{"2024-02-01", 2},
+		{"2024-02-02", 3},
+		{"2024-02-04", 1}, // Missed a day
+		{"2024-02-05", 2},
+	}
+	for _, tt := range tests {
+		if !stats.Daily.complete(tt.date) {
+			t.Errorf("complete(%s) = false; want true", tt.date)
+		}
+		if stats.Daily.Streak != tt.streak {
+			t.Errorf("streak after %s = %d; want %d", tt.date, stats.Daily.Streak, tt.streak)
+		}
+	}
+	if stats.Daily.complete("2024-02-03") {
+		t.Error("complete accepted a daily older than the last one solved")
+	}
+	if stats.Daily.BestStreak != 3 {
+		t.Errorf("best streak = %d; want 3", stats.Daily.BestStreak)
+	}
+	if got := stats.Daily.currentStreak("2024-02-06"); got != 2 {
+		t.Errorf("currentStreak the next day = %d; want 2", got)
+	}
+	if got := stats.Daily.currentStreak("2024-02-07"); got != 0 {
+		t.Errorf("currentStreak after missing a day = %d; want 0", got)
+	}
+}
+
+func TestRemovalStrategy(t *testing.T) {
+	game := setupTestGame(t)
+	game.difficulty = Medium
+	game.removal = logic.MirrorRemoval
+	game.startSeededGame(777)
+	waitForGame(t, game)
+
+	// Mirrored givens look the same reflected left to right
+	puzzle := game.logic.Puzzle
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if (puzzle[row][col] == 0) != (puzzle[row][8-col] == 0) {
+				t.Fatalf("givens at (%d,%d) and (%d,%d) differ: %v", row, col, row, 8-col, puzzle)
+			}
+		}
+	}
+
+	// The strategy is kept with the game, by name
+	data, err := json.Marshal(game.snapshot())
+	if err != nil {
+		t.Fatalf("failed to encode save: %v", err)
+	}
+	if !strings.Contains(string(data), `"removal":"mirror"`) {
+		t.Errorf("save %s doesn't record the mirror strategy", data)
+	}
+	var saved saveFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("failed to decode save: %v", err)
+	}
+	game.removal = logic.RandomRemoval
+	game.restore(&saved)
+	if game.layout != logic.MirrorRemoval || game.removal != logic.RandomRemoval {
+		t.Errorf("restored layout = %v, removal = %v; want the mirror game with random kept for new ones",
+			game.layout, game.removal)
+	}
+
+	if got := cycleRemoval(logic.RandomRemoval, -1); got != logic.MinimalRemoval {
+		t.Errorf("cycleRemoval(random, -1) = %v; want minimal", got)
+	}
+}
+
+func TestPuzzleQueue(t *testing.T) {
+	queue := newPuzzleQueue(nil)
+	defer queue.close()
+
+	game := setupTestGame(t)
+	game.queue = queue
+	game.difficulty = Easy
+	game.removal = logic.RotationalRemoval
+	game.startGame() // Nothing is ready yet, so this only waits
+	if game.state == Playing || game.pending == nil {
+		t.Fatalf("startGame with an empty queue left state %v, pending %v; want a wait", game.state, game.pending)
+	}
+	waitForGame(t, game)
+	if game.pending != nil || game.seed == 0 {
+		t.Errorf("after starting, pending = %v and seed = %d; want no wait and a seed", game.pending, game.seed)
+	}
+
+	// The queued puzzle can be rebuilt from its seed
+	queued := game.logic.Puzzle
+	game.startSeededGame(game.seed)
+	waitForGame(t, game)
+	if game.logic.Puzzle != queued {
+		t.Errorf("seed %d rebuilt %v; want the queued %v", game.seed, game.logic.Puzzle, queued)
+	}
+}
+
+// Test the queue only fills the settings picked last, within its workers
+func TestPuzzleQueueSelect(t *testing.T) {
+	queue := newPuzzleQueue(nil)
+	defer queue.close()
+
+	for _, removal := range logic.RemovalStrategies {
+		queue.selectKey(queueKey{Hard, removal})
+	}
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+	kept := queueKey{Hard, logic.MinimalRemoval}
+	if len(queue.batches) != 1 || queue.batches[kept] == nil {
+		t.Errorf("queue has jobs for %d keys; want only %v", len(queue.batches), kept)
+	}
+	if b := queue.batches[kept]; b != nil && b.inflight > queueSize {
+		t.Errorf("%d jobs for %v; want at most %d", b.inflight, kept, queueSize)
+	}
+	if cap(queue.workers) > max(1, runtime.NumCPU()/2) {
+		t.Errorf("queue allows %d jobs at once; want at most half the cores", cap(queue.workers))
+	}
+}
+
+// Test leaving the menu drops the puzzle it was waiting on
+func TestPendingGameCancelled(t *testing.T) {
+	game := setupTestGame(t)
+	game.state = SeedMenu
+	game.startSeededGame(424242)
+	if game.state != SeedMenu || game.pending == nil {
+		t.Fatalf("startSeededGame left state %v, pending %v; want to wait on the seed menu", game.state, game.pending)
+	}
+	puzzle := game.logic.Puzzle
+
+	game.state = MainMenu // ESC back to the menu
+	game.checkQueue()
+	if game.pending != nil {
+		t.Error("pending game kept after leaving the seed menu")
+	}
+	time.Sleep(50 * time.Millisecond)
+	game.checkQueue()
+	if game.state != MainMenu || game.logic.Puzzle != puzzle {
+		t.Errorf("cancelled puzzle still started, state %v", game.state)
+	}
+}
+
+// Test the headless subcommands on packs read from stdin
+func TestCommands(t *testing.T) {
+	puzzle := "53..7....6..195....98....6.8...6...34..8.3..17...2...6.6....28....419..5....8..79"
+	solution := "534678912672195348198342567859761423426853791713924856961537284287419635345286179"
+	broken := "55" + puzzle[2:]
+	run := func(cmd command, input string, args ...string) (string, string, error) {
+		var stdout, stderr strings.Builder
+		err := cmd(args, strings.NewReader(input), &stdout, &stderr)
+		return stdout.String(), stderr.String(), err
+	}
+
+	out, _, err := run(runSolve, puzzle+"\n")
+	if err != nil || out != solution+"\n" {
+		t.Errorf("solve = %q, %v; want %q", out, err, solution+"\n")
+	}
+	out, errOut, err := run(runSolve, puzzle+"\n"+broken+"\n")
+	if err == nil || out != solution+"\n" || !strings.Contains(errOut, "puzzle 2:") {
+		t.Errorf("solve with a broken puzzle = %q, %q, %v; want it reported and left out", out, errOut, err)
+	}
+
+	out, _, err = run(runGrade, puzzle+"\n")
+	if err != nil || !strings.HasPrefix(out, "1\tEasy\t") {
+		t.Errorf("grade = %q, %v; want puzzle 1 rated Easy", out, err)
+	}
+
+	out, _, err = run(runValidate, puzzle+"\n"+broken+"\n"+solution[:9]+strings.Repeat(".", 72)+"\n")
+	if err == nil || strings.Contains(out, "puzzle 1:") ||
+		!strings.Contains(out, "puzzle 2:") || !strings.Contains(out, "puzzle 3: puzzle has more than one solution") {
+		t.Errorf("validate = %q, %v; want puzzles 2 and 3 reported", out, err)
+	}
+
+	out, _, err = run(runConvert, puzzle+"\n", "-from", "line", "-to", "sdk")
+	if err != nil {
+		t.Fatalf("convert returned error: %v", err)
+	}
+	back, _, err := run(runConvert, out, "-to", "line")
+	if err != nil || back != puzzle+"\n" {
+		t.Errorf("converting back from sdk = %q, %v; want %q", back, err, puzzle+"\n")
+	}
+	if _, _, err := run(runConvert, puzzle+"\n"); err == nil {
+		t.Error("convert without -to should fail")
+	}
+
+	args := []string{"-n", "2", "-rating", "easy", "-seed", "9"}
+	first, _, err := run(runGenerate, "", args...)
+	if err != nil {
+		t.Fatalf("generate returned error: %v", err)
+	}
+	second, _, _ := run(runGenerate, "", args...)
+	if first != second {
+		t.Errorf("generate with the same seed gave %q then %q", first, second)
+	}
+	generated, err := logic.ReadPuzzles(strings.NewReader(first))
+	if err != nil || len(generated) != 2 {
+		t.Fatalf("generated %d puzzles, %v; want 2", len(generated), err)
+	}
+	for _, p := range generated {
+		if !logic.HasUniqueSolution(p) || ratingLevel(logic.Grade(p)) != Easy {
+			t.Errorf("generated %v isn't a unique easy puzzle", p)
+		}
+	}
+	if _, _, err := run(runGenerate, "", "-rating", "fiendish"); err == nil {
+		t.Error("generate -rating fiendish should fail")
+	}
+}
+
+// Test filling in candidates and clearing them from peers as numbers go in
+func TestCandidateFill(t *testing.T) {
+	game := setupTestGame(t)
+	game.state = Playing
+	answer := game.logic.Puzzle[0][0]
+	game.logic.Puzzle[0][0] = 0
+	game.logic.Givens[0][0] = false
+
+	if !game.logic.FillCandidates() {
+		t.Fatal("FillCandidates found nothing to fill")
+	}
+	want := logic.NewCandidates(game.logic.Puzzle)
+	if game.logic.Marks != [9][9]uint16(want) {
+		t.Errorf("marks after filling = %v; want %v", game.logic.Marks, want)
+	}
+	if game.logic.FillCandidates() {
+		t.Error("filling twice should record nothing")
+	}
+
+	// Pencil the number into peers along the row, column and box, and elsewhere
+	for _, cell := range []logic.Cell{{Row: 0, Col: 7}, {Row: 6, Col: 0}, {Row: 1, Col: 1}, {Row: 4, Col: 4}} {
+		if !game.logic.HasPencilMark(cell.Row, cell.Col, answer) {
+			game.logic.TogglePencilMark(cell.Row, cell.Col, answer)
+		}
+	}
+	filled := game.logic.Marks
+
+	// Placing the number takes it out of every peer's marks in one move
+	game.autoClear = true
+	game.cursorX, game.cursorY = 0, 0
+	game.enterNumber(answer)
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			peer := row == 0 || col == 0 || (row < 3 && col < 3)
+			if peer && game.logic.HasPencilMark(row, col, answer) {
+				t.Errorf("R%dC%d still has pencil mark %d", row+1, col+1, answer)
+			}
+			if !peer && game.logic.Marks[row][col] != filled[row][col] {
+				t.Errorf("R%dC%d marks changed although it isn't a peer", row+1, col+1)
+			}
+		}
+	}
+	game.undo()
+	if game.logic.Puzzle[0][0] != 0 || game.logic.Marks != filled {
+		t.Error("one undo should bring back the number's cell and all the peers' marks")
+	}
+	if !game.logic.HasPencilMark(4, 4, answer) {
+		t.Error("undo lost the mark outside the row, column and box")
+	}
+}
+
+// Test a cell takes all nine pencil marks, in any notation
+func TestPencilMarkStyles(t *testing.T) {
+	game := setupTestGame(t)
+	game.cursorX, game.cursorY = 4, 4
+	for num := 1; num <= 9; num++ {
+		game.togglePencilMark(num)
+	}
+	for num := 1; num <= 9; num++ {
+		if !game.logic.HasPencilMark(4, 4, num) {
+			t.Errorf("pencil mark %d missing after toggling all nine", num)
+		}
+	}
+
+	for s := gridMarks; s <= cornerMarks; s++ {
+		parsed, err := parseMarkStyle(strings.ToLower(s.String()))
+		if err != nil || parsed != s {
+			t.Errorf("parseMarkStyle(%q) = %v, %v; want %v", s, parsed, err, s)
+		}
+	}
+	if _, err := parseMarkStyle("margin"); err == nil {
+		t.Error("parseMarkStyle(margin) should fail")
+	}
+}
+
+// Test centre marks are kept apart from the pencil marks
+func TestCentreMarks(t *testing.T) {
+	game := setupTestGame(t)
+	game.logic = logic.NewGameLogic(logic.Puzzle{})
+	game.cursorX, game.cursorY = 4, 4
+
+	game.specialEnterMode, game.centreMode = true, true
+	game.togglePencilMark(2)
+	game.togglePencilMark(7)
+	game.centreMode = false
+	game.togglePencilMark(3)
+	if game.logic.Centre[4][4] != 1<<2|1<<7 || game.logic.Marks[4][4] != 1<<3 {
+		t.Fatalf("centre marks %09b, pencil marks %09b; want 2 and 7 in the centre, 3 pencilled",
+			game.logic.Centre[4][4]>>1, game.logic.Marks[4][4]>>1)
+	}
+
+	// Undo takes back one mark at a time from the set it was made in
+	game.logic.UndoMove()
+	game.logic.UndoCell(4, 4)
+	if game.logic.Centre[4][4] != 1<<2 || game.logic.Marks[4][4] != 0 {
+		t.Errorf("after undoing twice, centre marks %09b, pencil marks %09b; want only centre 2",
+			game.logic.Centre[4][4]>>1, game.logic.Marks[4][4]>>1)
+	}
+
+	// The marks are saved with the game
+	data, err := json.Marshal(game.logic)
+	if err != nil {
+		t.Fatalf("failed to encode game: %v", err)
+	}
+	var saved logic.GameLogic
+	if err := json.Unmarshal(data, &saved); err != nil || saved.Centre != game.logic.Centre {
+		t.Errorf("saved centre marks = %v, %v; want %v", saved.Centre[4], err, game.logic.Centre[4])
+	}
+
+	// Placing a number clears the cell's centre marks and, with auto clear,
+	// takes it out of the peers' centre marks
+	game.logic.ToggleCentreMark(4, 0, 2)
+	game.logic.ToggleCentreMark(0, 0, 2)
+	game.logic.SetCellAndEliminate(4, 4, 2)
+	if game.logic.Centre[4][4] != 0 || game.logic.HasCentreMark(4, 0, 2) || !game.logic.HasCentreMark(0, 0, 2) {
+		t.Errorf("after placing 2, centre marks R5C5 %09b, R5C1 %09b, R1C1 %09b; want only R1C1's 2 left",
+			game.logic.Centre[4][4]>>1, game.logic.Centre[4][0]>>1, game.logic.Centre[0][0]>>1)
+	}
+	game.logic.UndoMove()
+	if !game.logic.HasCentreMark(4, 4, 2) || !game.logic.HasCentreMark(4, 0, 2) {
+		t.Error("undoing the number should bring back the centre marks it cleared")
+	}
+
+	// Clearing the board's marks takes both sets
+	game.logic.TogglePencilMark(1, 1, 5)
+	game.logic.ClearPencilMarks()
+	if game.logic.Centre != [9][9]uint16{} || game.logic.Marks != [9][9]uint16{} {
+		t.Error("ClearPencilMarks left marks behind")
+	}
+	game.logic.UndoMove()
+	if !game.logic.HasCentreMark(0, 0, 2) || !game.logic.HasPencilMark(1, 1, 5) {
+		t.Error("undoing the clear should bring back both sets of marks")
+	}
+}
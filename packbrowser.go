@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/afroash/mygame/logic"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// progressVersion is bumped whenever the pack progress file layout changes
+const progressVersion = 1
+
+// puzzleProgress is what we remember about one pack puzzle
+type puzzleProgress struct {
+	Solved   bool          `json:"solved"`
+	BestTime time.Duration `json:"best_time,omitempty"`
+}
+
+// packProgress tracks which pack puzzles have been solved, saved as JSON
+// under the config dir
+type packProgress struct {
+	Version int                                  `json:"version"`
+	Packs   map[string]map[string]puzzleProgress `json:"packs"` // Pack key, then puzzle id
+	path    string
+}
+
+// progressPath returns where the pack progress is kept
+func progressPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "progress.json"), nil
+}
+
+// loadProgress reads the pack progress file, starting afresh if there is none yet
+func loadProgress(path string) (*packProgress, error) {
+	p := &packProgress{Version: progressVersion, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pack progress: %v", err)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to decode pack progress: %v", err)
+	}
+	if p.Version != progressVersion {
+		return nil, fmt.Errorf("unsupported pack progress version %d", p.Version)
+	}
+	return p, nil
+}
+
+// save writes the pack progress back to disk
+func (p *packProgress) save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pack progress: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create progress dir: %v", err)
+	}
+	if err := replaceFile(p.path, data); err != nil {
+		return fmt.Errorf("failed to write pack progress: %v", err)
+	}
+	return nil
+}
+
+// solved reports whether the puzzle has been solved before
+func (p *packProgress) solved(key, id string) bool {
+	return p != nil && p.Packs[key][id].Solved
+}
+
+// markSolved records a solved puzzle, keeping its best time
+func (p *packProgress) markSolved(key, id string, elapsed time.Duration) {
+	if p.Packs == nil {
+		p.Packs = make(map[string]map[string]puzzleProgress)
+	}
+	if p.Packs[key] == nil {
+		p.Packs[key] = make(map[string]puzzleProgress)
+	}
+	entry := p.Packs[key][id]
+	if !entry.Solved || elapsed < entry.BestTime {
+		entry.BestTime = elapsed
+	}
+	entry.Solved = true
+	p.Packs[key][id] = entry
+}
+
+// solvedCount returns how many of the pack's puzzles have been solved
+func (p *packProgress) solvedCount(pack *puzzlePack) int {
+	count := 0
+	for _, pp := range pack.Puzzles {
+		if p.solved(pack.Key, pp.ID) {
+			count++
+		}
+	}
+	return count
+}
+
+// nextPuzzle returns the index of the first unsolved puzzle in the pack,
+// starting over from the first once every puzzle is solved
+func (p *packProgress) nextPuzzle(pack *puzzlePack) int {
+	for i, pp := range pack.Puzzles {
+		if !p.solved(pack.Key, pp.ID) {
+			return i
+		}
+	}
+	return 0
+}
+
+// findPack returns the loaded pack with the key, nil if it isn't loaded
+func (g *Game) findPack(key string) *puzzlePack {
+	for i := range g.packs {
+		if g.packs[i].Key == key {
+			return &g.packs[i]
+		}
+	}
+	return nil
+}
+
+// openPackMenu shows the pack browser
+func (g *Game) openPackMenu() {
+	g.state = PackMenu
+	g.selected = 0
+}
+
+func (g *Game) handlePackMenu() {
+	if len(g.packs) == 0 {
+		return
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		g.selected = (g.selected + 1) % len(g.packs)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		g.selected--
+		if g.selected < 0 {
+			g.selected = len(g.packs) - 1
+		}
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		pack := &g.packs[g.selected]
+		g.playPackPuzzle(pack, g.progress.nextPuzzle(pack))
+	}
+}
+
+// playPackPuzzle starts the pack puzzle at index. Packs of finished grids,
+// like the default one, have clues removed at the selected difficulty and
+// with the selected strategy first.
+func (g *Game) playPackPuzzle(pack *puzzlePack, index int) {
+	pp := pack.Puzzles[index]
+	grid := pp.Puzzle
+	layout := logic.UnknownRemoval // Puzzles that come with their clues
+	if isFull(grid) {
+		layout = g.removal
+		logic.RemoveClues(nil, (*[9][9]int)(&grid), layout, clueRemoval(g.difficulty))
+	}
+
+	// The puzzle counts at the level it rates, the selected difficulty stays
+	// as it is for New Game
+	rating := logic.Grade(grid)
+	g.beginGame(grid, rating, layout)
+	g.packKey, g.packPuzzleID = pack.Key, pp.ID
+
+	details := pp.Difficulty
+	if details == "" {
+		details = g.level.String()
+	}
+	g.showStatus(fmt.Sprintf("%s #%s (%d of %d, %s)", pack.Title, pp.ID, index+1, len(pack.Puzzles), details),
+		infoMessage, normalMessageDuration)
+}
+
+// recordPackProgress marks the current pack puzzle as solved
+func (g *Game) recordPackProgress(elapsed time.Duration) {
+	if g.packKey == "" || g.progress == nil {
+		return
+	}
+	g.progress.markSolved(g.packKey, g.packPuzzleID, elapsed)
+	if err := g.progress.save(); err != nil {
+		g.showStatus(fmt.Sprintf("Error saving pack progress: %v", err), errorMessage, normalMessageDuration)
+		return
+	}
+	if pack := g.findPack(g.packKey); pack != nil {
+		g.showStatus(fmt.Sprintf("Solved %s #%s: %d of %d done", pack.Title, g.packPuzzleID,
+			g.progress.solvedCount(pack), len(pack.Puzzles)), successMessage, longMessageDuration)
+	}
+}
+
+// isFull reports whether every cell of the grid is filled
+func isFull(p logic.Puzzle) bool {
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if p[row][col] == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/afroash/mygame/logic"
+)
+
+// defaultPackData is the puzzle pack shipped inside the binary
+//
+//go:embed sample.txt
+var defaultPackData []byte
+
+// Name shown for the embedded puzzle pack
+const defaultPackName = "Default"
+
+// puzzlePack is a loaded collection of puzzles
+type puzzlePack struct {
+	logic.Pack
+	Key     string // Identifies the pack in the progress file
+	builtin bool   // The embedded pack, only played from New Game with -default-pack
+}
+
+// options are the settings taken from the command line and the config file
+type options struct {
+	Packs       []string `json:"packs"`        // Extra pack files or directories of packs
+	DefaultPack bool     `json:"default_pack"` // Use the embedded pack instead of generating grids
+	AutoClear   bool     `json:"auto_clear"`   // Placing a number removes it from its peers' pencil marks
+	MarkStyle   string   `json:"mark_style"`   // Pencil mark notation: grid or corner
+	Difficulty  string   `json:"-"`            // Level picked for new games, from --difficulty
+	Symmetry    string   `json:"-"`            // Clue layout picked for new games, from --symmetry
+	Seed        int64    `json:"-"`            // Game to start straight away, from --seed
+	HasSeed     bool     `json:"-"`
+}
+
+// stringList is a flag that can be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// parseFlags reads the command line options
+func parseFlags(args []string) (options, error) {
+	var opts options
+	var packs stringList
+
+	fs := flag.NewFlagSet("mygame", flag.ContinueOnError)
+	fs.Var(&packs, "pack", "puzzle pack `file or directory` to play from, can be repeated")
+	fs.BoolVar(&opts.DefaultPack, "default-pack", false, "play from the built in puzzle pack")
+	fs.BoolVar(&opts.AutoClear, "auto-clear", false, "remove placed numbers from the pencil marks they rule out")
+	fs.Int64Var(&opts.Seed, "seed", 0, "start straight away on the game made from `seed` at -difficulty and -symmetry;\n"+
+		"with -pack or -default-pack the same packs must be loaded to get the same puzzle")
+	fs.Func("difficulty", "`level` for new games: easy, medium or hard", func(s string) error {
+		_, err := parseDifficulty(s)
+		opts.Difficulty = s
+		return err
+	})
+	fs.Func("symmetry", "clue `layout` for new games: random, rotational, mirror, diagonal or minimal", func(s string) error {
+		_, err := logic.ParseRemovalStrategy(s)
+		opts.Symmetry = s
+		return err
+	})
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			opts.HasSeed = true
+		}
+	})
+	if opts.HasSeed && opts.Seed <= 0 {
+		return options{}, fmt.Errorf("invalid seed: %d", opts.Seed)
+	}
+	opts.Packs = packs
+	return opts, nil
+}
+
+// configPath returns where the config file is kept
+func configPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// loadConfig reads the config file. A missing file is not an error.
+func loadConfig(path string) (options, error) {
+	var opts options
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return opts, nil
+	}
+	if err != nil {
+		return opts, fmt.Errorf("failed to read config: %v", err)
+	}
+	if err := json.Unmarshal(data, &opts); err != nil {
+		return opts, fmt.Errorf("failed to decode config: %v", err)
+	}
+	return opts, nil
+}
+
+// merge adds the command line options to the ones from the config file
+func (o options) merge(flags options) options {
+	o.Packs = append(o.Packs, flags.Packs...)
+	o.DefaultPack = o.DefaultPack || flags.DefaultPack
+	o.AutoClear = o.AutoClear || flags.AutoClear
+	return o
+}
+
+// loadDefaultPack reads the embedded puzzle pack
+func loadDefaultPack() (puzzlePack, error) {
+	pack, err := logic.ReadPack(bytes.NewReader(defaultPackData))
+	if err != nil {
+		return puzzlePack{}, fmt.Errorf("failed to load default pack: %v", err)
+	}
+	if pack.Title == "" {
+		pack.Title = defaultPackName
+	}
+	return puzzlePack{Pack: *pack, Key: "default", builtin: true}, nil
+}
+
+// loadPackFile reads one pack file. Untitled packs are named after the file.
+func loadPackFile(path string) (puzzlePack, error) {
+	pack, err := logic.LoadPack(path)
+	if err != nil {
+		return puzzlePack{}, fmt.Errorf("%s: %v", path, err)
+	}
+	if len(pack.Puzzles) == 0 {
+		return puzzlePack{}, fmt.Errorf("%s: no puzzles found", path)
+	}
+	if pack.Title == "" {
+		pack.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+	return puzzlePack{Pack: *pack, Key: key}, nil
+}
+
+// loadPacks reads every pack in the given files and directories. Packs that
+// fail to load are skipped and their errors returned alongside the rest.
+func loadPacks(paths []string) ([]puzzlePack, []error) {
+	var packs []puzzlePack
+	var errs []error
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to open pack: %v", err))
+			continue
+		}
+
+		files := []string{path}
+		if info.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("failed to read pack dir: %v", err))
+				continue
+			}
+			files = files[:0]
+			for _, entry := range entries {
+				if !entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+			sort.Strings(files)
+		}
+
+		for _, file := range files {
+			pack, err := loadPackFile(file)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			packs = append(packs, pack)
+		}
+	}
+	return packs, errs
+}
+
+// loadPuzzleSources loads the embedded pack and the configured ones,
+// reporting any that failed in the status bar
+func (g *Game) loadPuzzleSources(opts options) {
+	g.packs = nil
+	g.useDefaultPack = opts.DefaultPack
+	var errs []error
+	if pack, err := loadDefaultPack(); err != nil {
+		errs = append(errs, err)
+	} else {
+		g.packs = append(g.packs, pack)
+	}
+	packs, packErrs := loadPacks(opts.Packs)
+	g.packs = append(g.packs, packs...)
+	errs = append(errs, packErrs...)
+
+	for _, err := range errs {
+		log.Printf("Error loading puzzles: %v", err)
+	}
+	if len(errs) == 1 {
+		g.showStatus(fmt.Sprintf("Error loading puzzles: %v", errs[0]), errorMessage, longMessageDuration)
+	} else if len(errs) > 1 {
+		g.showStatus(fmt.Sprintf("%d puzzle packs failed to load: %v", len(errs), errs[0]), errorMessage, longMessageDuration)
+	}
+}
+
+// packPuzzles returns the puzzles New Game draws from. With none, grids are
+// generated instead.
+func (g *Game) packPuzzles() []logic.Puzzle {
+	var puzzles []logic.Puzzle
+	for _, pack := range g.packs {
+		if pack.builtin && !g.useDefaultPack {
+			continue
+		}
+		puzzles = append(puzzles, pack.Grids()...)
+	}
+	return puzzles
+}
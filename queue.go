@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"sync"
+
+	"github.com/afroash/mygame/logic"
+)
+
+// Puzzles kept ready for each difficulty and clue layout
+const queueSize = 2
+
+// queueKey picks out the puzzles that suit the current settings
+type queueKey struct {
+	level   DifficultyLevel
+	removal logic.RemovalStrategy
+}
+
+// queuedPuzzle is a puzzle generated ahead of time, with the seed that rebuilds it
+type queuedPuzzle struct {
+	key    queueKey // Level and clue layout the puzzle was built for
+	seed   int64
+	puzzle logic.Puzzle
+	rating logic.Rating
+}
+
+// puzzleSpec tells the generator what puzzles suit a key. Sources are the
+// pack grids to shuffle, nil to generate fresh grids.
+func puzzleSpec(key queueKey, sources []logic.Puzzle) logic.Spec {
+	return logic.Spec{
+		Sources:  sources,
+		Removal:  key.removal,
+		Clues:    clueRemoval(key.level),
+		Accept:   func(r logic.Rating) bool { return ratingLevel(r) == key.level },
+		Attempts: maxGradeAttempts,
+	}
+}
+
+// puzzleQueue generates puzzles in the background so starting a game
+// doesn't have to wait on the generator. Only the settings picked for new
+// games are filled, and all jobs share a fixed number of workers.
+type puzzleQueue struct {
+	generator logic.Generator
+	sources   []logic.Puzzle
+	ctx       context.Context
+	cancel    context.CancelFunc
+	workers   chan struct{} // Held by each job while it generates
+
+	mu      sync.Mutex
+	ready   map[queueKey][]queuedPuzzle
+	batches map[queueKey]*queueBatch
+}
+
+// queueBatch is the unfinished jobs for one key, cancelled together when the
+// player picks other settings
+type queueBatch struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	inflight int
+}
+
+// newPuzzleQueue creates an empty queue building puzzles from the sources
+func newPuzzleQueue(sources []logic.Puzzle) *puzzleQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &puzzleQueue{
+		// One goroutine per job, on half the cores to leave some for drawing the game
+		generator: logic.Generator{Workers: 1},
+		sources:   sources,
+		ctx:       ctx,
+		cancel:    cancel,
+		workers:   make(chan struct{}, max(1, runtime.NumCPU()/2)),
+		ready:     make(map[queueKey][]queuedPuzzle),
+		batches:   make(map[queueKey]*queueBatch),
+	}
+}
+
+// selectKey makes the key the one being filled, cancelling the jobs of any
+// settings the player has moved away from. Puzzles already made are kept.
+func (q *puzzleQueue) selectKey(key queueKey) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for k, b := range q.batches {
+		if k != key {
+			b.cancel()
+			delete(q.batches, k)
+		}
+	}
+
+	b := q.batches[key]
+	if b == nil {
+		b = &queueBatch{}
+		b.ctx, b.cancel = context.WithCancel(q.ctx)
+		q.batches[key] = b
+	}
+	for n := len(q.ready[key]) + b.inflight; n < queueSize; n++ {
+		b.inflight++
+		go q.generate(key, b, newSeed())
+	}
+}
+
+func (q *puzzleQueue) generate(key queueKey, b *queueBatch, seed int64) {
+	var p queuedPuzzle
+	var err error
+	select {
+	case q.workers <- struct{}{}:
+		p = queuedPuzzle{key: key, seed: seed}
+		p.puzzle, p.rating, err = q.generator.Generate(b.ctx, seed, puzzleSpec(key, q.sources))
+		<-q.workers
+	case <-b.ctx.Done():
+		err = b.ctx.Err()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	b.inflight--
+	if err != nil {
+		if b.ctx.Err() == nil {
+			log.Printf("Error generating puzzle: %v", err)
+		}
+		return
+	}
+	q.ready[key] = append(q.ready[key], p)
+}
+
+// take hands out a ready puzzle for the key, if there is one, and starts
+// on its replacement
+func (q *puzzleQueue) take(key queueKey) (queuedPuzzle, bool) {
+	q.mu.Lock()
+	var p queuedPuzzle
+	ready := q.ready[key]
+	ok := len(ready) > 0
+	if ok {
+		p = ready[0]
+		q.ready[key] = ready[1:]
+	}
+	q.mu.Unlock()
+
+	q.selectKey(key)
+	return p, ok
+}
+
+// close stops any puzzles still being generated
+func (q *puzzleQueue) close() {
+	q.cancel()
+}
+
+// pendingGame is a puzzle being made for the menu that asked for it. It is
+// dropped if the player leaves that menu before the puzzle is ready.
+type pendingGame struct {
+	from   GameState
+	key    queueKey
+	result chan generated // nil when waiting on the queue instead
+	cancel context.CancelFunc
+	start  func(queuedPuzzle) // Plays the puzzle once it is ready
+}
+
+// generated is the outcome of building a puzzle in the background
+type generated struct {
+	puzzle queuedPuzzle
+	err    error
+}
+
+// generateGame builds the puzzle for the key and seed without holding up
+// the frame. checkQueue hands it to start once it is ready.
+func (g *Game) generateGame(key queueKey, seed int64, sources []logic.Puzzle, start func(queuedPuzzle)) {
+	g.cancelPending()
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan generated, 1)
+	go func() {
+		puzzle, rating, err := logic.Generator{}.Generate(ctx, seed, puzzleSpec(key, sources))
+		result <- generated{queuedPuzzle{key: key, seed: seed, puzzle: puzzle, rating: rating}, err}
+	}()
+	g.pending = &pendingGame{from: g.state, key: key, result: result, cancel: cancel, start: start}
+}
+
+// waitForQueue starts the queue's next puzzle for the key once it is ready
+func (g *Game) waitForQueue(key queueKey) {
+	g.cancelPending()
+	g.pending = &pendingGame{from: g.state, key: key, start: g.playGenerated}
+}
+
+// cancelPending drops the puzzle being made, if any
+func (g *Game) cancelPending() {
+	if g.pending == nil {
+		return
+	}
+	if g.pending.cancel != nil {
+		g.pending.cancel()
+	}
+	g.pending = nil
+}
+
+// checkQueue starts the pending game once its puzzle is ready, or drops it
+// when the player has moved on from the menu that asked for it
+func (g *Game) checkQueue() {
+	p := g.pending
+	if p == nil {
+		return
+	}
+	if g.state != p.from {
+		g.cancelPending()
+		return
+	}
+	if p.result == nil {
+		if ready, ok := g.queue.take(p.key); ok {
+			g.pending = nil
+			p.start(ready)
+		}
+		return
+	}
+	select {
+	case r := <-p.result:
+		g.pending = nil
+		if r.err != nil {
+			g.showStatus(fmt.Sprintf("Error generating puzzle: %v", r.err), errorMessage, normalMessageDuration)
+			return
+		}
+		p.start(r.puzzle)
+	default:
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/afroash/mygame/logic"
+)
+
+// saveVersion is bumped whenever the save file layout changes
+const saveVersion = 1
+
+// Name of the directory under the user config dir holding our files
+const configDirName = "mygame"
+
+// saveFile is the JSON layout of a saved game
+type saveFile struct {
+	Version    int                   `json:"version"`
+	Name       string                `json:"name,omitempty"` // Save slot the game belongs to
+	SavedAt    time.Time             `json:"saved_at"`
+	Game       logic.GameLogic       `json:"game"` // Puzzle, givens, pencil marks and move history
+	Difficulty DifficultyLevel       `json:"difficulty"`
+	Rating     logic.Rating          `json:"rating"`
+	Removal    logic.RemovalStrategy `json:"removal"` // Strategy the clues were removed with
+	Elapsed    time.Duration         `json:"elapsed"`
+	Pack       string                `json:"pack,omitempty"`      // Key of the pack the puzzle came from
+	PuzzleID   string                `json:"puzzle_id,omitempty"` // Id of the puzzle within the pack
+	Seed       int64                 `json:"seed,omitempty"`
+	Daily      string                `json:"daily,omitempty"` // Date of the daily puzzle
+}
+
+// configDir returns the directory our files live in. It is only created
+// when something is written to it.
+func configDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config dir: %v", err)
+	}
+	return filepath.Join(base, configDirName), nil
+}
+
+// autosavePath returns where the current game is saved on exit
+func autosavePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "autosave.json"), nil
+}
+
+// writeSave stores a save file, writing to a temporary file first so a crash
+// can't leave a half written save behind
+func writeSave(path string, s *saveFile) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode save: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create save dir: %v", err)
+	}
+	if err := replaceFile(path, data); err != nil {
+		return fmt.Errorf("failed to write save: %v", err)
+	}
+	return nil
+}
+
+// replaceFile writes data to a temporary file and renames it over path, so a
+// crash part way through leaves the old file rather than a truncated one
+func replaceFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// readSave loads a save file, rejecting versions we don't understand
+func readSave(path string) (*saveFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s saveFile
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("failed to decode save: %v", err)
+	}
+	if s.Version != saveVersion {
+		return nil, fmt.Errorf("unsupported save version %d", s.Version)
+	}
+	return &s, nil
+}
+
+// snapshot captures the current game for saving
+func (g *Game) snapshot() *saveFile {
+	return &saveFile{
+		Version:    saveVersion,
+		Name:       g.slotName,
+		SavedAt:    time.Now(),
+		Game:       *g.logic,
+		Difficulty: g.level,
+		Rating:     g.rating,
+		Removal:    g.layout,
+		Elapsed:    g.clock.Elapsed(),
+		Pack:       g.packKey,
+		PuzzleID:   g.packPuzzleID,
+		Seed:       g.seed,
+		Daily:      g.daily,
+	}
+}
+
+// restore replaces the current game with a saved one
+func (g *Game) restore(s *saveFile) {
+	gameLogic := s.Game
+	g.logic = &gameLogic
+	g.level = s.Difficulty
+	g.rating = s.Rating
+	g.layout = s.Removal
+	g.clock.Reset(s.Elapsed)
+	g.completionRecorded = gameLogic.GetGameStatus() == logic.Completed
+	g.slotName = s.Name
+	g.packKey, g.packPuzzleID = s.Pack, s.PuzzleID
+	g.seed = s.Seed
+	g.daily = s.Daily
+	g.cancelPending()
+
+	g.hint = nil
+	g.hintEliminations = [9][9]uint16{}
+	g.showWinMessage = false
+	g.messageTimer = 0
+	g.state = Playing
+}
+
+// autosave writes the game in progress to the autosave file. Finished games
+// remove the file instead so there is nothing left to continue.
+func (g *Game) autosave() error {
+	if g.savePath == "" || g.logic == nil {
+		return nil
+	}
+	if g.logic.GetGameStatus() == logic.Completed {
+		g.canContinue = false
+		if err := os.Remove(g.savePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove save: %v", err)
+		}
+		return nil
+	}
+	if err := writeSave(g.savePath, g.snapshot()); err != nil {
+		return err
+	}
+	g.canContinue = true
+	return nil
+}
+
+// continueGame resumes the game in progress, or the autosave if there is none
+func (g *Game) continueGame() {
+	if g.logic != nil && g.logic.GetGameStatus() != logic.Completed {
+		g.state = Playing
+		return
+	}
+	if g.savePath == "" {
+		return
+	}
+	s, err := readSave(g.savePath)
+	if err != nil {
+		g.canContinue = false
+		g.showStatus(fmt.Sprintf("Could not load save: %v", err), errorMessage, longMessageDuration)
+		return
+	}
+	g.restore(s)
+	g.showStatus("Welcome back!", infoMessage, shortMessageDuration)
+}
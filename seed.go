@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Seeds are kept below a billion so they are easy to read out and type
+const maxSeed = 1_000_000_000
+
+// Longest seed accepted on the seed screen
+const maxSeedLength = 18
+
+// newSeed picks a seed for a new game
+func newSeed() int64 {
+	return rand.Int63n(maxSeed-1) + 1
+}
+
+// parseSeed reads a seed typed by the player
+func parseSeed(s string) (int64, error) {
+	seed, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil || seed <= 0 {
+		return 0, fmt.Errorf("invalid seed: %q", s)
+	}
+	return seed, nil
+}
+
+// startSeededGame starts a game built entirely from the seed, so the same
+// seed, difficulty and removal strategy always give the same puzzle. The
+// puzzle is generated in the background and checkQueue starts it.
+func (g *Game) startSeededGame(seed int64) {
+	g.generateGame(queueKey{g.difficulty, g.removal}, seed, g.packPuzzles(), g.playGenerated)
+	g.showStatus(fmt.Sprintf("Generating the puzzle for seed %d...", seed), infoMessage, longMessageDuration)
+}
+
+// playGenerated starts a generated puzzle, keeping its seed, level and clue
+// layout to share
+func (g *Game) playGenerated(p queuedPuzzle) {
+	g.beginGame(p.puzzle, p.rating, p.key.removal)
+	g.seed = p.seed
+	if g.level != p.key.level {
+		// The generator settled for the closest puzzle it found
+		g.showStatus(fmt.Sprintf("No %v puzzle found for seed %d, playing a %v one", p.key.level, p.seed, g.level),
+			warningMessage, longMessageDuration)
+		return
+	}
+	g.showStatus(
+		fmt.Sprintf("%v puzzle: needs %v (score %d)", g.level, p.rating.Hardest, p.rating.Score),
+		infoMessage,
+		normalMessageDuration,
+	)
+}
+
+// openSeedMenu shows the screen for typing in a seed
+func (g *Game) openSeedMenu() {
+	g.state = SeedMenu
+	g.seedBuffer = g.seedBuffer[:0]
+}
+
+// handleSeedMenu edits the seed being typed and starts the game on Enter
+func (g *Game) handleSeedMenu() {
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if r >= '0' && r <= '9' && len(g.seedBuffer) < maxSeedLength {
+			g.seedBuffer = append(g.seedBuffer, r)
+		}
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(g.seedBuffer) > 0 {
+		g.seedBuffer = g.seedBuffer[:len(g.seedBuffer)-1]
+	}
+
+	// The seed only makes the same puzzle at the same difficulty and clue layout
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft) && g.difficulty > Easy {
+		g.difficulty--
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowRight) && g.difficulty < Hard {
+		g.difficulty++
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) {
+		g.changeRemoval(-1)
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) {
+		g.changeRemoval(1)
+	}
+
+	if !inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		return
+	}
+	seed, err := parseSeed(string(g.seedBuffer))
+	if err != nil {
+		g.showStatus(err.Error(), errorMessage, normalMessageDuration)
+		return
+	}
+	g.startSeededGame(seed)
+}
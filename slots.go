@@ -0,0 +1,257 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/afroash/mygame/logic"
+)
+
+// Name of the directory under the config dir holding the named save slots
+const slotsDirName = "slots"
+
+// slotInfo describes a named save slot for the load screen
+type slotInfo struct {
+	Name       string
+	SavedAt    time.Time
+	Difficulty DifficultyLevel
+	Progress   int // Percentage of the open cells filled in
+}
+
+// slotManager keeps named save slots as one save file each in a directory
+type slotManager struct {
+	dir string
+}
+
+// newSlotManager manages the slots stored in dir
+func newSlotManager(dir string) *slotManager {
+	return &slotManager{dir: dir}
+}
+
+// defaultSlotManager returns the slot manager for the user config dir
+func defaultSlotManager() (*slotManager, error) {
+	dir, err := configDir()
+	if err != nil {
+		return nil, err
+	}
+	return newSlotManager(filepath.Join(dir, slotsDirName)), nil
+}
+
+// slotFileName turns a slot name into a safe file name. Names that only
+// differ in case or punctuation share a file, see slotManager.owner.
+func slotFileName(name string) (string, error) {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	file := strings.Trim(b.String(), "-")
+	if file == "" {
+		return "", fmt.Errorf("invalid slot name: %q", name)
+	}
+	return file + ".json", nil
+}
+
+func (m *slotManager) path(name string) (string, error) {
+	file, err := slotFileName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(m.dir, file), nil
+}
+
+// owner returns the name of the slot stored at path, empty if there is none
+// or it can't be read
+func (m *slotManager) owner(path string) string {
+	s, err := readSave(path)
+	if err != nil {
+		return ""
+	}
+	return s.Name
+}
+
+// errSlotClash reports a name whose file already holds another slot
+func errSlotClash(name, owner string) error {
+	return fmt.Errorf("%q is too close to the existing slot %q, pick another name", name, owner)
+}
+
+// List returns every slot, most recently saved first
+func (m *slotManager) List() ([]slotInfo, error) {
+	entries, err := os.ReadDir(m.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read slots: %v", err)
+	}
+
+	var slots []slotInfo
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		s, err := readSave(filepath.Join(m.dir, entry.Name()))
+		if err != nil {
+			continue // Skip files we can't read rather than hiding every slot
+		}
+		slots = append(slots, slotInfo{
+			Name:       s.Name,
+			SavedAt:    s.SavedAt,
+			Difficulty: s.Difficulty,
+			Progress:   progress(&s.Game),
+		})
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].SavedAt.After(slots[j].SavedAt)
+	})
+	return slots, nil
+}
+
+// Save writes the game to the named slot, replacing what was there. It
+// refuses to overwrite a different slot whose name maps to the same file.
+func (m *slotManager) Save(name string, s *saveFile) error {
+	path, err := m.path(name)
+	if err != nil {
+		return err
+	}
+	name = strings.TrimSpace(name)
+	if owner := m.owner(path); owner != "" && owner != name {
+		return errSlotClash(name, owner)
+	}
+	s.Name = name
+	return writeSave(path, s)
+}
+
+// Load reads the game in the named slot
+func (m *slotManager) Load(name string) (*saveFile, error) {
+	path, err := m.path(name)
+	if err != nil {
+		return nil, err
+	}
+	return readSave(path)
+}
+
+// Rename moves a slot to a new name, refusing to overwrite another slot
+func (m *slotManager) Rename(oldName, newName string) error {
+	oldPath, err := m.path(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := m.path(newName)
+	if err != nil {
+		return err
+	}
+	s, err := readSave(oldPath)
+	if err != nil {
+		return err
+	}
+	if newPath != oldPath {
+		if owner := m.owner(newPath); owner != "" {
+			return errSlotClash(strings.TrimSpace(newName), owner)
+		}
+		if _, err := os.Stat(newPath); err == nil {
+			return fmt.Errorf("a slot named %q already exists", newName)
+		}
+	}
+
+	s.Name = strings.TrimSpace(newName)
+	if err := writeSave(newPath, s); err != nil {
+		return err
+	}
+	if newPath != oldPath {
+		if err := os.Remove(oldPath); err != nil {
+			return fmt.Errorf("failed to remove old slot: %v", err)
+		}
+	}
+	return nil
+}
+
+// Delete removes the named slot
+func (m *slotManager) Delete(name string) error {
+	path, err := m.path(name)
+	if err != nil {
+		return err
+	}
+	if owner := m.owner(path); owner != "" && owner != strings.TrimSpace(name) {
+		return errSlotClash(strings.TrimSpace(name), owner)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to delete slot: %v", err)
+	}
+	return nil
+}
+
+// progress returns the percentage of the non-given cells the player has filled
+func progress(g *logic.GameLogic) int {
+	open, filled := 0, 0
+	for row := 0; row < 9; row++ {
+		for col := 0; col < 9; col++ {
+			if g.IsGiven(row, col) {
+				continue
+			}
+			open++
+			if g.Puzzle[row][col] != 0 {
+				filled++
+			}
+		}
+	}
+	if open == 0 {
+		return 100
+	}
+	return filled * 100 / open
+}
+
+// saveToSlot stores the current game in its slot, picking a name for games
+// that haven't been saved to one yet
+func (g *Game) saveToSlot() {
+	if g.slots == nil || g.logic == nil {
+		g.showStatus("Save slots are not available", errorMessage, normalMessageDuration)
+		return
+	}
+	if g.slotName == "" {
+		g.slotName = fmt.Sprintf("%v %s", g.level, time.Now().Format("2006-01-02 15.04.05"))
+	}
+	if err := g.slots.Save(g.slotName, g.snapshot()); err != nil {
+		g.showStatus(fmt.Sprintf("Error saving game: %v", err), errorMessage, longMessageDuration)
+		return
+	}
+	g.showStatus(fmt.Sprintf("Saved to %q", g.slotName), successMessage, normalMessageDuration)
+}
+
+// openLoadMenu refreshes the slot list and shows the load screen
+func (g *Game) openLoadMenu() {
+	g.refreshSlots()
+	g.state = LoadMenu
+	g.selected = 0
+	g.renaming = false
+	g.confirmDelete = false
+}
+
+// refreshSlots rereads the slot list from disk
+func (g *Game) refreshSlots() {
+	g.slotList = nil
+	if g.slots == nil {
+		return
+	}
+	slots, err := g.slots.List()
+	if err != nil {
+		g.showStatus(err.Error(), errorMessage, longMessageDuration)
+		return
+	}
+	g.slotList = slots
+	if g.selected >= len(slots) {
+		g.selected = len(slots) - 1
+	}
+	if g.selected < 0 {
+		g.selected = 0
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// statsVersion is bumped whenever the statistics file layout changes
+const statsVersion = 1
+
+// levelStats are the totals kept for one difficulty level
+type levelStats struct {
+	Started   int           `json:"started"`
+	Completed int           `json:"completed"`
+	BestTime  time.Duration `json:"best_time"`  // Fastest completed game, 0 if none yet
+	TotalTime time.Duration `json:"total_time"` // Sum over completed games, for the average
+	Mistakes  int           `json:"mistakes"`
+	Hints     int           `json:"hints"`
+}
+
+// AverageTime returns the mean time of the completed games
+func (l levelStats) AverageTime() time.Duration {
+	if l.Completed == 0 {
+		return 0
+	}
+	return l.TotalTime / time.Duration(l.Completed)
+}
+
+// statsStore holds the local statistics, saved as JSON under the config dir
+type statsStore struct {
+	Version int                  `json:"version"`
+	Levels  [Hard + 1]levelStats `json:"levels"` // Indexed by DifficultyLevel
+	Daily   dailyStats           `json:"daily"`
+	path    string
+}
+
+// statsPath returns where the statistics are kept
+func statsPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "stats.json"), nil
+}
+
+// loadStats reads the statistics file, starting afresh if there is none yet
+func loadStats(path string) (*statsStore, error) {
+	s := &statsStore{Version: statsVersion, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats: %v", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to decode stats: %v", err)
+	}
+	if s.Version != statsVersion {
+		return nil, fmt.Errorf("unsupported stats version %d", s.Version)
+	}
+	return s, nil
+}
+
+// save writes the statistics back to disk
+func (s *statsStore) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode stats: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create stats dir: %v", err)
+	}
+	if err := replaceFile(s.path, data); err != nil {
+		return fmt.Errorf("failed to write stats: %v", err)
+	}
+	return nil
+}
+
+// level returns the totals for a difficulty level, nil for unknown levels
+func (s *statsStore) level(d DifficultyLevel) *levelStats {
+	if d < 0 || int(d) >= len(s.Levels) {
+		return nil
+	}
+	return &s.Levels[d]
+}
+
+// recordStat applies a change to the current level's totals and saves them.
+// Statistics are optional, so without a store this does nothing.
+func (g *Game) recordStat(change func(l *levelStats)) {
+	if g.stats == nil {
+		return
+	}
+	l := g.stats.level(g.level)
+	if l == nil {
+		return
+	}
+	change(l)
+	if err := g.stats.save(); err != nil {
+		g.showStatus(fmt.Sprintf("Error saving statistics: %v", err), errorMessage, normalMessageDuration)
+	}
+}
+
+// recordCompletion counts the finished puzzle once, tracking personal bests
+func (g *Game) recordCompletion() {
+	if g.completionRecorded {
+		return
+	}
+	g.completionRecorded = true
+	g.clock.Stop()
+	elapsed := g.clock.Elapsed()
+
+	newBest := false
+	g.recordStat(func(l *levelStats) {
+		l.Completed++
+		l.TotalTime += elapsed
+		if l.BestTime == 0 || elapsed < l.BestTime {
+			l.BestTime = elapsed
+			newBest = true
+		}
+	})
+	g.recordPackProgress(elapsed)
+	if newBest && g.stats != nil {
+		g.showStatus(fmt.Sprintf("Puzzle Completed! New best time: %s", formatDuration(elapsed)),
+			successMessage, longMessageDuration)
+	}
+	g.recordDaily()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/afroash/mygame/logic"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// gameClock measures play time with the wall clock, only counting while it
+// is running so paused and unfocused time is left out
+type gameClock struct {
+	banked  time.Duration    // Time counted before the current run
+	started time.Time        // When the current run began, zero while stopped
+	now     func() time.Time // Clock source, time.Now when nil
+}
+
+func (c *gameClock) currentTime() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+// Start begins counting, doing nothing if the clock is already running
+func (c *gameClock) Start() {
+	if c.started.IsZero() {
+		c.started = c.currentTime()
+	}
+}
+
+// Stop banks the time of the current run and stops counting
+func (c *gameClock) Stop() {
+	if !c.started.IsZero() {
+		c.banked += c.currentTime().Sub(c.started)
+		c.started = time.Time{}
+	}
+}
+
+// Running reports whether the clock is counting
+func (c *gameClock) Running() bool {
+	return !c.started.IsZero()
+}
+
+// Elapsed returns the total time counted so far
+func (c *gameClock) Elapsed() time.Duration {
+	if c.started.IsZero() {
+		return c.banked
+	}
+	return c.banked + c.currentTime().Sub(c.started)
+}
+
+// Reset stops the clock and sets the time counted so far
+func (c *gameClock) Reset(elapsed time.Duration) {
+	c.banked = elapsed
+	c.started = time.Time{}
+}
+
+// formatDuration shows a duration as m:ss, or h:mm:ss past the hour
+func formatDuration(d time.Duration) string {
+	seconds := int(d / time.Second)
+	if seconds >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// updateClock runs the clock only while an unfinished puzzle is on screen.
+// Losing window focus pauses the game so the grid is hidden too.
+func (g *Game) updateClock() {
+	if g.state == Playing && !ebiten.IsFocused() {
+		g.pause()
+	}
+	if g.state == Playing && g.logic != nil && g.logic.GetGameStatus() != logic.Completed {
+		g.clock.Start()
+	} else {
+		g.clock.Stop()
+	}
+}
+
+// pause stops the clock and hides the grid until the player resumes
+func (g *Game) pause() {
+	g.clock.Stop()
+	g.state = Paused
+}
+
+// handlePausedInput resumes the game from the pause screen
+func (g *Game) handlePausedInput() {
+	if inpututil.IsKeyJustPressed(ebiten.KeyTab) ||
+		inpututil.IsKeyJustPressed(ebiten.KeyEnter) ||
+		inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		g.state = Playing
+	}
+}